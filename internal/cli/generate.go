@@ -1,11 +1,18 @@
 package cli
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"go/token"
+	"go/types"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/mickamy/injector/internal/config"
 	"github.com/mickamy/injector/internal/gen"
@@ -33,6 +40,9 @@ func (a *App) runGenerate(args []string) int {
 	outFile := flags.Output
 	if outFile == "" {
 		outFile = "injector_gen.go"
+		if flags.Tests {
+			outFile = "injector_gen_test.go"
+		}
 	}
 
 	if flags.Verbose {
@@ -45,11 +55,54 @@ func (a *App) runGenerate(args []string) int {
 			prints.Fprintln(a.out, "must:", flags.Must)
 			prints.Fprintln(a.out, "on-error:", flags.OnError)
 		}
+		if flags.Strict {
+			prints.Fprintln(a.out, "strict:", flags.Strict)
+		}
+		if flags.Exclude != "" {
+			prints.Fprintln(a.out, "exclude:", flags.Exclude)
+		}
+		if flags.ImplicitBind {
+			prints.Fprintln(a.out, "implicit-bind:", flags.ImplicitBind)
+		}
+		if flags.Context {
+			prints.Fprintln(a.out, "context:", flags.Context)
+		}
+		if flags.Profile != "" {
+			prints.Fprintln(a.out, "profile:", flags.Profile)
+		}
+		if flags.Tests {
+			prints.Fprintln(a.out, "tests:", flags.Tests)
+		}
+	}
+
+	variants, err := parseVariants(flags.Variants, outFile)
+	if err != nil {
+		prints.Fprintln(a.err, wrapFlagError(err))
+		return 2
+	}
+	if len(variants) == 0 {
+		variants = []variant{{File: outFile}}
+	}
+
+	code := 0
+	for _, v := range variants {
+		if c := a.generate(patterns, flags, v); c != 0 {
+			code = c
+		}
+	}
+	return code
+}
+
+// generate loads the packages for the build configuration v and writes its generated files.
+func (a *App) generate(patterns []string, flags generateFlags, v variant) int {
+	if flags.Verbose && v.Spec != "" {
+		prints.Fprintf(a.out, "variant: %s -> %s\n", v.Spec, v.File)
 	}
 
 	loaded, err := workspace.Load(patterns, workspace.LoadConfig{
-		BuildTags: splitTags(flags.Tags),
-		Tests:     false,
+		BuildTags: append(splitList(flags.Tags), v.Tags...),
+		Tests:     flags.Tests,
+		Env:       v.env(),
 	})
 	if err != nil {
 		prints.Fprintln(a.err, err.Error())
@@ -60,7 +113,13 @@ func (a *App) runGenerate(args []string) int {
 		prints.Fprintln(a.out, "number of packages:", len(loaded.Packages))
 	}
 
-	containers, err := scan.CollectContainers(loaded.Packages)
+	pkgs := loaded.Packages
+	var containers []scan.ContainerSpec
+	if flags.Tests {
+		pkgs, containers, err = testPackages(pkgs)
+	} else {
+		containers, err = scan.CollectContainers(pkgs)
+	}
 	if err != nil {
 		prints.Fprintln(a.err, err.Error())
 		return 1
@@ -70,7 +129,13 @@ func (a *App) runGenerate(args []string) int {
 		return 1
 	}
 
-	providers, err := scan.CollectProviders(loaded.Packages)
+	providers, skipped, err := scan.CollectProviders(pkgs, scan.ProviderOptions{
+		Strict:  flags.Strict,
+		Exclude: splitList(flags.Exclude),
+		Modules: scan.ModuleTypes(containers),
+		Structs: scan.StructTypes(containers),
+		Fields:  scan.FieldsTypes(containers),
+	})
 	if err != nil {
 		prints.Fprintln(a.err, err.Error())
 		return 1
@@ -91,7 +156,14 @@ func (a *App) runGenerate(args []string) int {
 
 		prints.Fprintln(a.out, "providers:", len(providers))
 		for _, p := range providers {
-			prints.Fprintf(a.out, "provider: %s.%s -> %s (%s)\n", p.PkgPath, p.Name, p.ResultString, p.Position)
+			prints.Fprintf(a.out, "provider: %s.%s -> %s%s (%s)\n", p.PkgPath, p.Name, p.ResultString, providerAttrs(p), p.Position)
+		}
+
+		if len(skipped) > 0 {
+			prints.Fprintln(a.out, "skipped:", len(skipped))
+			for _, s := range skipped {
+				prints.Fprintf(a.out, "skipped: %s.%s: %s (%s)\n", s.PkgPath, s.Name, s.Reason, s.Position)
+			}
 		}
 	}
 
@@ -102,8 +174,9 @@ func (a *App) runGenerate(args []string) int {
 	}
 
 	var failed bool
-	emitInputs := make(map[string]gen.EmitInput)
-	for _, c := range containers {
+
+	containerFields := make([][]resolve.ContainerField, len(containers))
+	for i, c := range containers {
 		fields, err := resolve.ConvertContainerFields(c)
 		if err != nil {
 			prints.Fprintln(a.err, err.Error())
@@ -115,53 +188,87 @@ func (a *App) runGenerate(args []string) int {
 			failed = true
 			continue
 		}
+		containerFields[i] = fields
+	}
 
-		g, err := resolve.BuildGraph(fields, rproviders)
+	// Each profile gets constructors of its own, suffixed with the profile name when there are several.
+	profiles := splitList(flags.Profile)
+	runs := profiles
+	if len(runs) == 0 {
+		runs = []string{""}
+	}
+	built := make([][]*gen.Container, len(runs))
+	for k, profile := range runs {
+		var suffix string
+		if len(profiles) > 1 {
+			suffix = profileSuffix(profile)
+		}
+		cs, ok, err := a.buildContainers(containers, containerFields, rproviders, flags, profile, suffix)
 		if err != nil {
-			prints.Fprintln(a.err, fmt.Sprintf("failed to build graph for container %s.%s: %v", c.PkgPath, c.Name, err))
+			prints.Fprintln(a.err, err.Error())
+			prints.Fprintln(a.err, "generation failed")
+			return 1
+		}
+		if !ok {
 			failed = true
-			continue
 		}
+		built[k] = cs
+	}
 
-		ordered, err := resolve.OrderProviders(g)
-		if err != nil {
-			prints.Fprintln(a.err, err.Error())
+	emitInputs := make(map[string]gen.EmitInput)
+	constraints := make(map[string]scan.ContainerSpec)
+	for i, c := range containers {
+		outPath := outputPath(c, v.File, flags.Tests)
+
+		// The generated file shares the build constraint of the container files.
+		if prev, ok := constraints[outPath]; ok && prev.BuildConstraint != c.BuildConstraint {
+			prints.Fprintf(a.err, "containers %s.%s and %s.%s are generated into %s but have different build constraints\n", prev.PkgPath, prev.Name, c.PkgPath, c.Name, outPath)
 			failed = true
 			continue
 		}
-
-		if len(ordered) == 0 {
-			prints.Fprintf(
-				a.err,
-				"resolve: no providers selected for container %s.%s\n",
-				c.PkgPath,
-				c.Name,
-			)
+		constraints[outPath] = c
+		line, err := buildConstraintLine(c.BuildConstraint, v)
+		if err != nil {
+			prints.Fprintf(a.err, "%s: invalid build constraint: %v\n", c.Position, err)
 			failed = true
 			continue
 		}
 
-		outDir := filepath.Dir(positionToFile(c.Position))
-		outPath := filepath.Join(outDir, outFile)
-		if _, ok := emitInputs[outPath]; ok {
-			emitInputs[outPath] = emitInputs[outPath].Append(gen.Container{
-				Name:      c.Name,
-				Fields:    fields,
-				Providers: ordered,
-				PkgPath:   c.PkgPath,
-				FuncName:  "New" + c.Name,
-			})
-		} else {
-			emitInputs[outPath] = gen.EmitInput{
-				PackageName: c.PkgName,
-				OnError:     flags.OnError,
-				Containers: []gen.Container{{
-					Name:      c.Name,
-					Fields:    fields,
-					Providers: ordered,
-					PkgPath:   c.PkgPath,
-					FuncName:  "New" + c.Name,
-				}},
+		for k := range runs {
+			container := built[k][i]
+			if container == nil {
+				continue
+			}
+
+			if in, ok := emitInputs[outPath]; ok {
+				if in.PackageName != c.PkgName {
+					prints.Fprintf(a.err, "containers of packages %s and %s are generated into %s\n", in.PackageName, c.PkgName, outPath)
+					failed = true
+					continue
+				}
+				emitInputs[outPath] = in.Append(*container)
+			} else {
+				emitInputs[outPath] = gen.EmitInput{
+					PackageName:     c.PkgName,
+					OnError:         flags.OnError,
+					Containers:      []gen.Container{*container},
+					BuildConstraint: line,
+				}
+			}
+		}
+	}
+
+	if v.StaleFile != "" {
+		stale := make(map[string]struct{})
+		for _, c := range containers {
+			outPath := outputPath(c, v.StaleFile, flags.Tests)
+			if _, ok := stale[outPath]; ok {
+				continue
+			}
+			stale[outPath] = struct{}{}
+			if err := a.removeStale(outPath); err != nil {
+				prints.Fprintln(a.err, err.Error())
+				failed = true
 			}
 		}
 	}
@@ -200,6 +307,174 @@ func (a *App) runGenerate(args []string) int {
 	return 0
 }
 
+// buildContainers resolves the graph of every container for profile,
+// naming the constructors "New" + container name + suffix.
+// The result is aligned with containers; a container that fails to resolve is reported to a.err and left nil,
+// and ok is false. A cycle between containers is returned as an error.
+func (a *App) buildContainers(
+	containers []scan.ContainerSpec,
+	containerFields [][]resolve.ContainerField,
+	providers []*resolve.Provider,
+	flags generateFlags,
+	profile string,
+	suffix string,
+) ([]*gen.Container, bool, error) {
+	ok := true
+
+	// Containers are providers of each other through their generated constructors.
+	rproviders := slices.Clone(providers)
+	ctors := make([]*resolve.Provider, len(containers))
+	for i, c := range containers {
+		fields := containerFields[i]
+		if fields == nil {
+			continue
+		}
+
+		cps := resolve.ContainerProviders(c, fields, "New"+c.Name+suffix, flags.Context)
+		if len(cps) == 0 {
+			continue
+		}
+		ctors[i] = cps[0]
+		rproviders = append(rproviders, cps...)
+		if flags.Verbose {
+			prints.Fprintf(a.out, "provider: %s -> %s container (%s)\n", cps[0].NameWithPkg, types.TypeString(cps[0].ResultType, (*types.Package).Name), c.Position)
+		}
+	}
+
+	built := make([]*gen.Container, len(containers))
+	for i, c := range containers {
+		fields := containerFields[i]
+		if fields == nil {
+			continue
+		}
+
+		g, err := resolve.BuildGraph(fields, rproviders, resolve.Options{
+			ImplicitBindings: flags.ImplicitBind,
+			Context:          flags.Context,
+			PkgPath:          c.PkgPath,
+			Profile:          profile,
+		})
+		if err != nil {
+			prints.Fprintln(a.err, fmt.Sprintf("failed to build graph for container %s.%s%s: %v", c.PkgPath, c.Name, profileString(profile), err))
+			ok = false
+			continue
+		}
+
+		for _, u := range g.Unused {
+			prints.Fprintf(a.err, "warning: %s.%s: field %s: with:%s=%s is never used\n", c.PkgPath, c.Name, u.Field, u.Override.Type, u.Override.Provider)
+		}
+
+		if flags.Verbose {
+			for _, m := range g.Missing {
+				prints.Fprintf(a.out, "optional: %s.%s: no provider for %s (required by %s), using the zero value\n", c.PkgPath, c.Name, missingString(m), m.RequiredBy)
+			}
+		}
+
+		ordered, err := resolve.OrderNodes(g)
+		if err != nil {
+			prints.Fprintln(a.err, err.Error())
+			ok = false
+			continue
+		}
+
+		lazy, err := resolve.OrderLazyNodes(g)
+		if err != nil {
+			prints.Fprintln(a.err, err.Error())
+			ok = false
+			continue
+		}
+
+		if flags.Verbose {
+			printGraph(a.out, c, profile, fields, g, ordered, lazy)
+		}
+
+		lifecycle := resolve.LifecycleNodes(ordered, resolve.Lifecycle{
+			Start: flags.StartMethod,
+			Stop:  flags.StopMethod,
+		})
+
+		if len(ordered) == 0 && len(lazy.Nodes()) == 0 {
+			prints.Fprintf(
+				a.err,
+				"resolve: no providers selected for container %s.%s\n",
+				c.PkgPath,
+				c.Name,
+			)
+			ok = false
+			continue
+		}
+
+		built[i] = &gen.Container{
+			Name:      c.Name,
+			Fields:    fields,
+			Roots:     g.Roots,
+			Nodes:     ordered,
+			Lazy:      lazy,
+			Args:      g.Args,
+			Context:   g.Context,
+			Missing:   g.Missing,
+			Lifecycle: lifecycle,
+			LifecycleMethods: resolve.Lifecycle{
+				Start: flags.StartMethod,
+				Stop:  flags.StopMethod,
+			},
+			PkgPath:  c.PkgPath,
+			FuncName: "New" + c.Name + suffix,
+			Profile:  profile,
+		}
+	}
+
+	if err := setContainerResults(containers, ctors, built); err != nil {
+		return nil, false, err
+	}
+	return built, ok, nil
+}
+
+// profileSuffix returns the constructor name suffix of a profile (local -> Local).
+func profileSuffix(profile string) string {
+	r, size := utf8.DecodeRuneInString(profile)
+	return string(unicode.ToUpper(r)) + profile[size:]
+}
+
+// profileString formats a profile for messages, or returns "" without a profile.
+func profileString(profile string) string {
+	if profile == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (profile %s)", profile)
+}
+
+// outputPath returns the path of the file named file generated for the container c.
+func outputPath(c scan.ContainerSpec, file string, tests bool) string {
+	outDir := filepath.Dir(positionToFile(c.Position))
+	if tests && strings.HasSuffix(c.PkgName, "_test") {
+		// External test packages share the directory of the package they test.
+		return filepath.Join(outDir, strings.TrimSuffix(file, "_test.go")+"_ext_test.go")
+	}
+	return filepath.Join(outDir, file)
+}
+
+// removeStale removes the file at path if it was written by the generator,
+// and warns about it otherwise, since its declarations may conflict with the generated ones.
+func (a *App) removeStale(path string) error {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(b, []byte(gen.GeneratedComment+"\n")) {
+		prints.Fprintf(a.err, "warning: %s was not generated by injector and may conflict with the variants\n", path)
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	prints.Fprintln(a.out, "remove:", path)
+	return nil
+}
+
 func (a *App) write(bytes []byte, outPath string) error {
 	f, err := os.OpenFile(outPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -219,11 +494,20 @@ func (a *App) write(bytes []byte, outPath string) error {
 
 // generateFlags holds flags for the `generate` subcommand.
 type generateFlags struct {
-	Output  string
-	Must    bool
-	OnError *config.OnError
-	Tags    string
-	Verbose bool
+	Output       string
+	Must         bool
+	OnError      *config.OnError
+	Tags         string
+	Strict       bool
+	Exclude      string
+	ImplicitBind bool
+	Context      bool
+	Profile      string
+	Variants     []string
+	Tests        bool
+	StartMethod  string
+	StopMethod   string
+	Verbose      bool
 }
 
 // parseGenerateFlags parses flags for `injector generate`.
@@ -238,6 +522,15 @@ func parseGenerateFlags(args []string) (generateFlags, []string, error) {
 	fs.StringVar(&gf.Tags, "tags", "", "comma-separated build tags (optional)")
 	fs.BoolVar(&gf.Must, "must", false, "generate MustNew* constructors that crash on failure (optional)")
 	fs.StringVar(&onErrorRaw, "on-error", "", "error handling for MustNew* (panic|fatal). Requires --must (default: panic)")
+	fs.BoolVar(&gf.Strict, "strict", false, "collect only providers annotated with //injector:provide (optional)")
+	fs.StringVar(&gf.Exclude, "exclude", "", "comma-separated package path globs excluded from provider discovery (optional)")
+	fs.BoolVar(&gf.ImplicitBind, "implicit-bind", false, "satisfy interfaces with the only provider implementing them (optional)")
+	fs.BoolVar(&gf.Context, "context", false, "generate constructors that accept a context.Context (optional)")
+	fs.StringVar(&gf.Profile, "profile", "", "comma-separated profiles whose providers take precedence; several generate a constructor per profile (optional)")
+	fs.Var((*listFlag)(&gf.Variants), "variant", "build tags and GOOS/GOARCH of a variant generated into a file of its own; repeatable (optional)")
+	fs.BoolVar(&gf.Tests, "tests", false, "generate containers declared in _test.go files into a test file (optional)")
+	fs.StringVar(&gf.StartMethod, "start-method", "Start", "method that starts a lifecycle component (optional)")
+	fs.StringVar(&gf.StopMethod, "stop-method", "Stop", "method that stops a lifecycle component (optional)")
 	fs.BoolVar(&gf.Verbose, "v", false, "enable verbose output")
 	fs.BoolVar(&gf.Verbose, "verbose", false, "enable verbose output")
 
@@ -253,6 +546,27 @@ func parseGenerateFlags(args []string) (generateFlags, []string, error) {
 		gf.OnError = &onError
 	}
 
+	for _, m := range []string{gf.StartMethod, gf.StopMethod} {
+		if !token.IsIdentifier(m) || !token.IsExported(m) {
+			return generateFlags{}, nil, fmt.Errorf("invalid lifecycle method %q: must be an exported identifier", m)
+		}
+	}
+
+	suffixes := map[string]string{}
+	for _, p := range splitList(gf.Profile) {
+		if !token.IsIdentifier(p) {
+			return generateFlags{}, nil, fmt.Errorf("invalid profile %q: must be an identifier", p)
+		}
+		if prev, ok := suffixes[profileSuffix(p)]; ok {
+			return generateFlags{}, nil, fmt.Errorf("profile %q conflicts with %q", p, prev)
+		}
+		suffixes[profileSuffix(p)] = p
+	}
+
+	if gf.Tests && gf.Output != "" && !strings.HasSuffix(gf.Output, "_test.go") {
+		return generateFlags{}, nil, fmt.Errorf("invalid output %q: --tests requires a _test.go file", gf.Output)
+	}
+
 	if gf.Must && gf.OnError == nil {
 		gf.OnError = &config.OnErrorPanic
 	}
@@ -272,10 +586,31 @@ func generateUsage() string {
 		"",
 		"Flags:",
 		"  -o, --output      output file name (default: injector_gen.go)",
+		"  --strict          collect only providers annotated with //injector:provide",
+		"  --exclude         comma-separated package path globs excluded from provider discovery",
+		"  --implicit-bind   satisfy interfaces with the only provider implementing them",
+		"  --context         generate constructors that accept a context.Context",
+		"  --profile         comma-separated profiles; several generate NewX<Profile> constructors",
+		"  --variant         generate a variant for build tags and GOOS/GOARCH (e.g. integration,linux/amd64); repeatable",
+		"  --tests           generate containers declared in _test.go files (default output: injector_gen_test.go)",
+		"  --start-method    method that starts a lifecycle component (default: Start)",
+		"  --stop-method     method that stops a lifecycle component (default: Stop)",
 		"  -v, --verbose     enable verbose output",
 	}, "\n")
 }
 
+// listFlag is a flag that may be repeated, collecting its values.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *listFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
 // wrapFlagError turns a flag parsing error into a human-friendly message.
 func wrapFlagError(err error) string {
 	if err == nil {
@@ -284,8 +619,8 @@ func wrapFlagError(err error) string {
 	return fmt.Sprintf("%v\n\n%s", err, generateUsage())
 }
 
-// splitTags splits a comma-separated build tag string into a slice.
-func splitTags(s string) []string {
+// splitList splits a comma-separated flag value (e.g. build tags) into a slice.
+func splitList(s string) []string {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return nil
@@ -316,3 +651,156 @@ func positionToFile(pos string) string {
 	}
 	return pos[:j]
 }
+
+// setContainerResults sets the results of the constructors of the built containers.
+// A constructor that calls the constructor of another container returns its error and cleanup too,
+// so the containers are visited in dependency order; a cycle between containers is an error.
+func setContainerResults(containers []scan.ContainerSpec, ctors []*resolve.Provider, built []*gen.Container) error {
+	index := map[*resolve.Provider]int{}
+	for i, p := range ctors {
+		if p != nil {
+			index[p] = i
+		}
+	}
+
+	done := make([]bool, len(ctors))
+	var path []int
+	var visit func(i int) error
+	visit = func(i int) error {
+		if done[i] || built[i] == nil {
+			return nil
+		}
+		if j := slices.Index(path, i); j >= 0 {
+			var names []string
+			for _, k := range append(path[j:], i) {
+				names = append(names, containers[k].PkgPath+"."+containers[k].Name)
+			}
+			return fmt.Errorf("resolve: containers depend on each other: %s", strings.Join(names, " -> "))
+		}
+		path = append(path, i)
+
+		for _, n := range append(slices.Clone(built[i].Nodes), built[i].Lazy.Nodes()...) {
+			if j, ok := index[n.Provider]; ok {
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+
+		if ctors[i] != nil {
+			resolve.SetContainerResults(ctors[i], built[i].Nodes)
+		}
+		path = path[:len(path)-1]
+		done[i] = true
+		return nil
+	}
+
+	for i := range built {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printGraph writes the nodes of a container in construction order with the nodes each one receives,
+// then the node assigned to each field. Nodes of the same provider are numbered (e.g. NewBuffer#2).
+func printGraph(w io.Writer, c scan.ContainerSpec, profile string, fields []resolve.ContainerField, g *resolve.Graph, ordered []*resolve.Node, lazy resolve.LazyOrder) {
+	nodes := append(slices.Clone(ordered), lazy.Nodes()...)
+
+	labels := map[*resolve.Node]string{}
+	count := map[string]int{}
+	for _, n := range nodes {
+		count[nodeLabel(n)]++
+	}
+	seen := map[string]int{}
+	for _, n := range nodes {
+		label := nodeLabel(n)
+		if count[label] > 1 {
+			seen[label]++
+			label = fmt.Sprintf("%s#%d", label, seen[label])
+		}
+		labels[n] = label
+	}
+
+	prints.Fprintf(w, "graph: %s.%s%s\n", c.PkgPath, c.Name, profileString(profile))
+	for _, n := range nodes {
+		if len(n.Deps) == 0 {
+			prints.Fprintf(w, "  node: %s\n", labels[n])
+			continue
+		}
+		deps := make([]string, len(n.Deps))
+		for i, d := range n.Deps {
+			deps[i] = labels[d]
+		}
+		prints.Fprintf(w, "  node: %s <- %s\n", labels[n], strings.Join(deps, ", "))
+	}
+	var i int
+	for _, f := range fields {
+		if !f.Resolvable() {
+			continue
+		}
+		if i < len(g.Roots) {
+			prints.Fprintf(w, "  field: %s <- %s\n", f.Name, labels[g.Roots[i]])
+		}
+		i++
+	}
+}
+
+// nodeLabel names the provider of n for verbose output.
+func nodeLabel(n *resolve.Node) string {
+	p := n.Provider
+	switch p.Kind {
+	case resolve.ProviderArg:
+		return "arg:" + p.Name
+	default:
+		return p.NameWithPkg
+	}
+}
+
+// providerAttrs formats the annotations of a provider for verbose output.
+func providerAttrs(p scan.ProviderSpec) string {
+	var b strings.Builder
+	if p.Var {
+		b.WriteString(" var")
+	}
+	if p.Transient {
+		b.WriteString(" transient")
+	}
+	if p.Struct {
+		b.WriteString(" struct")
+		if len(p.Fields) > 0 {
+			b.WriteString(" fields=" + strings.Join(p.Fields, ","))
+		}
+	}
+	if p.FieldOf != nil {
+		b.WriteString(" field of " + types.TypeString(p.FieldOf, (*types.Package).Name))
+	}
+	if p.Qualifier != "" {
+		b.WriteString(fmt.Sprintf(" name=%q", p.Qualifier))
+	}
+	for _, g := range p.Groups {
+		b.WriteString(fmt.Sprintf(" group=%s", g.Name))
+		if g.Priority != 0 {
+			b.WriteString(fmt.Sprintf(" priority=%d", g.Priority))
+		}
+		if g.Key != "" {
+			b.WriteString(fmt.Sprintf(" key=%s", g.Key))
+		}
+	}
+	return b.String()
+}
+
+// missingString formats the binding of a missing optional dependency for verbose output.
+func missingString(m resolve.Missing) string {
+	s := types.TypeString(m.Type, func(p *types.Package) string {
+		if p == nil {
+			return ""
+		}
+		return p.Path()
+	})
+	if m.Name != "" {
+		s += fmt.Sprintf(" name=%q", m.Name)
+	}
+	return s
+}
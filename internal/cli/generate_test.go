@@ -0,0 +1,20 @@
+package cli
+
+import "testing"
+
+func TestProfileSuffix(t *testing.T) {
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{profile: "local", want: "Local"},
+		{profile: "Prod", want: "Prod"},
+		{profile: "éte", want: "Éte"},
+		{profile: "_test", want: "_test"},
+	}
+	for _, tt := range tests {
+		if got := profileSuffix(tt.profile); got != tt.want {
+			t.Errorf("profileSuffix(%q) = %q, want %q", tt.profile, got, tt.want)
+		}
+	}
+}
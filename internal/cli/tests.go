@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/mickamy/injector/internal/scan"
+)
+
+// testPackages selects the packages scanned in --tests mode and returns the containers declared in their _test.go files.
+//
+// Packages are loaded with their tests, so a package with test files appears twice:
+// as built for its dependents, and as built for its own tests ("p [p.test]"), with its _test.go files.
+// A package that declares a test container, itself or in its external test package, is scanned as built for its tests,
+// so its test providers are found and its types are those the test container refers to.
+// Other packages are scanned as built for their dependents.
+func testPackages(pkgs []*packages.Package) ([]*packages.Package, []scan.ContainerSpec, error) {
+	var plain, tests []*packages.Package
+	for _, pkg := range pkgs {
+		if pkg == nil {
+			continue
+		}
+		switch {
+		case pkg.ID == pkg.PkgPath && strings.HasSuffix(pkg.ID, ".test"):
+			// The generated main package of the test binary.
+			continue
+		case pkg.ID != pkg.PkgPath:
+			tests = append(tests, pkg)
+		default:
+			plain = append(plain, pkg)
+		}
+	}
+	if len(tests) == 0 {
+		return nil, nil, nil
+	}
+
+	containers, err := scan.CollectContainers(tests)
+	if err != nil {
+		return nil, nil, err
+	}
+	containers = slices.DeleteFunc(containers, func(c scan.ContainerSpec) bool {
+		return !strings.HasSuffix(positionToFile(c.Position), "_test.go")
+	})
+
+	tested := map[string]bool{}
+	for _, c := range containers {
+		tested[strings.TrimSuffix(c.PkgPath, "_test")] = true
+	}
+
+	var out []*packages.Package
+	replaced := map[string]bool{}
+	for _, pkg := range tests {
+		if tested[strings.TrimSuffix(pkg.PkgPath, "_test")] {
+			out = append(out, pkg)
+			replaced[pkg.PkgPath] = true
+		}
+	}
+	for _, pkg := range plain {
+		if !replaced[pkg.PkgPath] {
+			out = append(out, pkg)
+		}
+	}
+	return out, containers, nil
+}
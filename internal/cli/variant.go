@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"fmt"
+	"go/build/constraint"
+	"slices"
+	"strings"
+)
+
+// defaultVariant is the --variant value of the build configuration without variant tags,
+// generated into the output file itself.
+const defaultVariant = "default"
+
+// variant is a build configuration generated into a file of its own, set by --variant.
+type variant struct {
+	// Spec is the --variant value, or "" without variants.
+	Spec string
+	// Tags are build tags added to --tags when loading the packages.
+	Tags []string
+	// GOOS and GOARCH select the target platform, if set.
+	GOOS   string
+	GOARCH string
+	// File is the output file name.
+	File string
+	// Constraint is the build constraint of the output file, or nil.
+	Constraint constraint.Expr
+	// StaleFile is the output file of a generation without variants, which conflicts with the variant files.
+	// It is set when no default variant is generated, and removed if it was written by the generator.
+	StaleFile string
+}
+
+// env returns the environment of `go list` for v.
+func (v variant) env() []string {
+	if v.GOOS == "" {
+		return nil
+	}
+	return []string{"GOOS=" + v.GOOS, "GOARCH=" + v.GOARCH}
+}
+
+// matches reports whether every build selecting v also selects o, ignoring other variants.
+func (v variant) matches(o variant) bool {
+	for _, t := range o.Tags {
+		if !slices.Contains(v.Tags, t) {
+			return false
+		}
+	}
+	return (o.GOOS == "" || o.GOOS == v.GOOS) && (o.GOARCH == "" || o.GOARCH == v.GOARCH)
+}
+
+// disjoint reports whether no build selects both v and o.
+func (v variant) disjoint(o variant) bool {
+	return (v.GOOS != "" && o.GOOS != "" && v.GOOS != o.GOOS) ||
+		(v.GOARCH != "" && o.GOARCH != "" && v.GOARCH != o.GOARCH)
+}
+
+// parseVariants parses --variant values.
+// A value is a comma-separated list of build tags and at most one GOOS/GOARCH pair (e.g. integration,linux/amd64),
+// or "default" for the configuration without them.
+//
+// Each variant is written to outFile suffixed with its tags and platform (injector_gen.integration.linux_amd64.go),
+// and the default variant to outFile itself. The build constraints of the files are exclusive:
+// when several variants match a build, the first one is used, and the default variant matches no other variant.
+func parseVariants(specs []string, outFile string) ([]variant, error) {
+	var out []variant
+	files := map[string]string{}
+	for _, spec := range specs {
+		v, err := parseVariant(spec, outFile)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := files[v.File]; ok {
+			return nil, fmt.Errorf("variant %q conflicts with %q", spec, prev)
+		}
+		files[v.File] = spec
+		out = append(out, v)
+	}
+
+	for i := range out {
+		v := &out[i]
+		if v.Spec == defaultVariant {
+			continue
+		}
+		for _, prev := range out[:i] {
+			if prev.Spec == defaultVariant || v.disjoint(prev) {
+				continue
+			}
+			if v.matches(prev) {
+				return nil, fmt.Errorf("variant %q is never used: %q comes first and matches the same builds", v.Spec, prev.Spec)
+			}
+			v.Constraint = &constraint.AndExpr{X: v.Constraint, Y: &constraint.NotExpr{X: variantExpr(prev)}}
+		}
+	}
+
+	hasDefault := slices.ContainsFunc(out, func(v variant) bool { return v.Spec == defaultVariant })
+	for i := range out {
+		v := &out[i]
+		if !hasDefault {
+			v.StaleFile = outFile
+		}
+		if v.Spec != defaultVariant {
+			continue
+		}
+		for _, o := range out {
+			if o.Spec == defaultVariant {
+				continue
+			}
+			not := &constraint.NotExpr{X: variantExpr(o)}
+			if v.Constraint == nil {
+				v.Constraint = not
+			} else {
+				v.Constraint = &constraint.AndExpr{X: v.Constraint, Y: not}
+			}
+		}
+	}
+	return out, nil
+}
+
+// parseVariant parses a single --variant value. Its constraint is not yet exclusive of the other variants.
+func parseVariant(spec, outFile string) (variant, error) {
+	v := variant{Spec: spec}
+	if spec == defaultVariant {
+		v.File = outFile
+		return v, nil
+	}
+
+	var names []string
+	for _, elem := range splitList(spec) {
+		if goos, goarch, ok := strings.Cut(elem, "/"); ok {
+			if goos == "" || goarch == "" || !isBuildTag(goos) || !isBuildTag(goarch) {
+				return variant{}, fmt.Errorf("invalid variant %q: platform %q must be GOOS/GOARCH", spec, elem)
+			}
+			if v.GOOS != "" {
+				return variant{}, fmt.Errorf("invalid variant %q: more than one platform", spec)
+			}
+			v.GOOS, v.GOARCH = goos, goarch
+			continue
+		}
+		if !isBuildTag(elem) {
+			return variant{}, fmt.Errorf("invalid variant %q: invalid build tag %q", spec, elem)
+		}
+		if slices.Contains(v.Tags, elem) {
+			return variant{}, fmt.Errorf("invalid variant %q: build tag %q is repeated", spec, elem)
+		}
+		v.Tags = append(v.Tags, elem)
+		names = append(names, elem)
+	}
+	if len(v.Tags) == 0 && v.GOOS == "" {
+		return variant{}, fmt.Errorf("invalid variant %q: no build tags or platform", spec)
+	}
+
+	if v.GOOS != "" {
+		names = append(names, v.GOOS+"_"+v.GOARCH)
+	}
+	// The names follow a dot, so the go command ignores them:
+	// a tag or platform such as windows or linux_amd64 does not become an implicit build constraint.
+	// A test file keeps its _test.go suffix, and no other file gains one (e.g. from a tag such as fake_test).
+	base, suffix := strings.TrimSuffix(outFile, ".go"), ".go"
+	if strings.HasSuffix(outFile, "_test.go") {
+		base, suffix = strings.TrimSuffix(outFile, "_test.go"), "_test.go"
+	}
+	v.File = base + "." + strings.Join(names, ".") + suffix
+	if strings.HasSuffix(v.File, "_test.go") != strings.HasSuffix(outFile, "_test.go") {
+		return variant{}, fmt.Errorf("invalid variant %q: file name %s would be a test file", spec, v.File)
+	}
+	v.Constraint = variantExpr(v)
+	return v, nil
+}
+
+// variantExpr returns the build constraint satisfied by the tags and platform of v.
+func variantExpr(v variant) constraint.Expr {
+	var x constraint.Expr
+	for _, t := range append(append([]string{}, v.Tags...), v.GOOS, v.GOARCH) {
+		if t == "" {
+			continue
+		}
+		tag := &constraint.TagExpr{Tag: t}
+		if x == nil {
+			x = tag
+		} else {
+			x = &constraint.AndExpr{X: x, Y: tag}
+		}
+	}
+	return x
+}
+
+// buildConstraintLine returns the `//go:build` line of a generated file,
+// combining the constraint of the container file with the constraint of the variant.
+func buildConstraintLine(containerLine string, v variant) (string, error) {
+	x := v.Constraint
+	if containerLine != "" {
+		cx, err := constraint.Parse(containerLine)
+		if err != nil {
+			return "", err
+		}
+		if x == nil {
+			x = cx
+		} else {
+			x = &constraint.AndExpr{X: cx, Y: x}
+		}
+	}
+	if x == nil {
+		return "", nil
+	}
+	return "//go:build " + x.String(), nil
+}
+
+// isBuildTag reports whether s is a valid build tag: letters, digits, underscores and dots.
+func isBuildTag(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c != '_' && c != '.' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
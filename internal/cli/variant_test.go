@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"bytes"
+	"go/build"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mickamy/injector/internal/gen"
+)
+
+func TestParseVariantsFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []string
+		outFile string
+		want    []string
+	}{
+		{
+			name:    "tags and platforms",
+			specs:   []string{"integration,linux/amd64", "linux/amd64", "go1.21", "default"},
+			outFile: "injector_gen.go",
+			want:    []string{"injector_gen.integration.linux_amd64.go", "injector_gen.linux_amd64.go", "injector_gen.go1.21.go", "injector_gen.go"},
+		},
+		{
+			name:    "tags named like file name constraints",
+			specs:   []string{"windows", "test", "amd64"},
+			outFile: "injector_gen.go",
+			want:    []string{"injector_gen.windows.go", "injector_gen.test.go", "injector_gen.amd64.go"},
+		},
+		{
+			name:    "test output",
+			specs:   []string{"integration", "default"},
+			outFile: "injector_gen_test.go",
+			want:    []string{"injector_gen.integration_test.go", "injector_gen_test.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vs, err := parseVariants(tt.specs, tt.outFile)
+			if err != nil {
+				t.Fatalf("parseVariants: %v", err)
+			}
+			var got []string
+			for _, v := range vs {
+				got = append(got, v.File)
+
+				// The go command derives no constraint from the file name, and no test file from a non-test output.
+				// MatchFile only reads the (missing) file once its name matches.
+				ctxt := build.Default
+				ctxt.GOOS, ctxt.GOARCH = "plan9", "386"
+				if ok, err := ctxt.MatchFile(t.TempDir(), v.File); err == nil && !ok {
+					t.Errorf("file %s has a file name constraint", v.File)
+				}
+				if strings.HasSuffix(v.File, "_test.go") != strings.HasSuffix(tt.outFile, "_test.go") {
+					t.Errorf("file %s changes whether the output is a test file", v.File)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVariantsExclusive(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []string
+	}{
+		{
+			name:  "overlapping tags and platforms",
+			specs: []string{"integration,linux/amd64", "linux/amd64", "integration", "darwin/arm64", "default"},
+		},
+		{
+			name:  "tags only",
+			specs: []string{"integration,e2e", "e2e", "integration", "default"},
+		},
+		{
+			name:  "default first",
+			specs: []string{"default", "e2e", "linux/amd64"},
+		},
+	}
+
+	tags := []string{"integration", "e2e"}
+	platforms := [][2]string{{"linux", "amd64"}, {"linux", "arm64"}, {"darwin", "arm64"}, {"windows", "amd64"}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vs, err := parseVariants(tt.specs, "injector_gen.go")
+			if err != nil {
+				t.Fatalf("parseVariants: %v", err)
+			}
+
+			// Every build selects exactly one variant: the first one it matches.
+			for set := 0; set < 1<<len(tags); set++ {
+				for _, p := range platforms {
+					enabled := map[string]bool{p[0]: true, p[1]: true}
+					for i, tag := range tags {
+						enabled[tag] = set&(1<<i) != 0
+					}
+					target := variant{GOOS: p[0], GOARCH: p[1]}
+					for _, tag := range tags {
+						if enabled[tag] {
+							target.Tags = append(target.Tags, tag)
+						}
+					}
+
+					var selected []string
+					for _, v := range vs {
+						if v.Constraint == nil || v.Constraint.Eval(func(tag string) bool { return enabled[tag] }) {
+							selected = append(selected, v.Spec)
+						}
+					}
+					want := defaultVariant
+					for _, v := range vs {
+						if v.Spec != defaultVariant && target.matches(v) {
+							want = v.Spec
+							break
+						}
+					}
+					if len(selected) != 1 || selected[0] != want {
+						t.Errorf("build %v selects %v, want [%s]", target, selected, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseVariantsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []string
+		outFile string
+		want    string
+	}{
+		{
+			name:    "shadowed variant",
+			specs:   []string{"integration", "integration,linux/amd64"},
+			outFile: "injector_gen.go",
+			want:    "is never used",
+		},
+		{
+			name:    "test file from a tag",
+			specs:   []string{"fake_test"},
+			outFile: "injector_gen.go",
+			want:    "would be a test file",
+		},
+		{
+			name:    "same file",
+			specs:   []string{"integration", "integration"},
+			outFile: "injector_gen.go",
+			want:    "conflicts with",
+		},
+		{
+			name:    "invalid platform",
+			specs:   []string{"linux/"},
+			outFile: "injector_gen.go",
+			want:    "must be GOOS/GOARCH",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseVariants(tt.specs, tt.outFile)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseVariants error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVariantsStaleFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []string
+		want  string
+	}{
+		{name: "without default", specs: []string{"integration", "linux/amd64"}, want: "injector_gen.go"},
+		{name: "with default", specs: []string{"integration", "default"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vs, err := parseVariants(tt.specs, "injector_gen.go")
+			if err != nil {
+				t.Fatalf("parseVariants: %v", err)
+			}
+			for _, v := range vs {
+				if v.StaleFile != tt.want {
+					t.Errorf("variant %q: StaleFile = %q, want %q", v.Spec, v.StaleFile, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveStale(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantKept bool
+		wantErr  string
+	}{
+		{name: "generated file", content: gen.GeneratedComment + "\n\npackage app\n", wantKept: false},
+		{name: "user file", content: "package app\n", wantKept: true, wantErr: "warning: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "injector_gen.go")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var out, errOut bytes.Buffer
+			a := &App{out: &out, err: &errOut}
+			if err := a.removeStale(path); err != nil {
+				t.Fatalf("removeStale: %v", err)
+			}
+			_, err := os.Stat(path)
+			if kept := err == nil; kept != tt.wantKept {
+				t.Errorf("kept = %v, want %v", kept, tt.wantKept)
+			}
+			if !strings.Contains(errOut.String(), tt.wantErr) {
+				t.Errorf("stderr = %q, want %q", errOut.String(), tt.wantErr)
+			}
+			if err := a.removeStale(path + ".missing"); err != nil {
+				t.Errorf("removeStale of a missing file: %v", err)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"go/format"
+	"go/token"
 	"go/types"
 	"path"
 	"slices"
@@ -16,17 +17,97 @@ import (
 	"github.com/mickamy/injector/internal/resolve"
 )
 
+// GeneratedComment is the first line of the generated files.
+const GeneratedComment = "// Code generated by injector. DO NOT EDIT."
+
 type Container struct {
 	// Name is the struct type name.
 	Name string
 	// Fields are container fields (including "_" override fields which will be ignored in the final struct literal).
 	Fields []resolve.ContainerField
-	// Providers is the list of providers in execution order (dependencies first).
-	Providers []*resolve.Provider
+	// Roots are the resolved nodes of the resolvable fields, in field order.
+	Roots []*resolve.Node
+	// Nodes is the list of graph nodes in execution order (dependencies first).
+	Nodes []*resolve.Node
+	// Lazy lists the nodes built on the first use of a lazy field; Lazy.Fields is aligned with Roots.
+	Lazy resolve.LazyOrder
+	// Args are the nodes of `inject:"arg"` fields; they become constructor parameters in this order.
+	Args []*resolve.Node
+	// Context is the node of the context.Context parameter, if any; it precedes Args.
+	Context *resolve.Node
+	// Missing lists the optional dependencies without a provider, documented on the constructor.
+	Missing []resolve.Missing
+	// Lifecycle are the nodes whose values have lifecycle methods, in start order.
+	// They are also stored in the `inject:"lifecycle"` field, if the container declares one.
+	Lifecycle []*resolve.Node
+	// LifecycleMethods names the component methods called by the generated Start and Stop.
+	LifecycleMethods resolve.Lifecycle
 	// PkgPath is used to decide whether a provider call needs an import qualifier.
 	PkgPath string
 	// FuncName is the generated constructor function name.
 	FuncName string
+	// Profile is the profile the constructor is resolved with, if any.
+	// A container may have a constructor per profile; its methods are written once.
+	Profile string
+}
+
+// lifecycleNames returns the names of the type and the variable that hold
+// the lifecycle hooks of the containers built by the generated constructors.
+func (c Container) lifecycleNames() (typeName, varName string) {
+	base := lowerFirst(c.Name) + "Lifecycle"
+	return base, base + "s"
+}
+
+// lazyFields returns the resolvable fields marked lazy, keyed by their index in Roots.
+func (c Container) lazyFields() map[int]resolve.ContainerField {
+	out := map[int]resolve.ContainerField{}
+	i := 0
+	for _, f := range c.Fields {
+		if !f.Resolvable() {
+			continue
+		}
+		if f.Inject.Lazy {
+			out[i] = f
+		}
+		i++
+	}
+	return out
+}
+
+// usesOnce reports whether a lazy field has nodes of its own to build on first use.
+func (c Container) usesOnce() bool {
+	return len(c.Lazy.Nodes()) > 0
+}
+
+// returnsError reports whether any provider in the container returns an error.
+func (c Container) returnsError() bool {
+	return slices.ContainsFunc(c.Nodes, func(n *resolve.Node) bool {
+		return n.Provider.ReturnError
+	})
+}
+
+// params returns the nodes of the constructor parameters, in order.
+func (c Container) params() []*resolve.Node {
+	if c.Context == nil {
+		return c.Args
+	}
+	return append([]*resolve.Node{c.Context}, c.Args...)
+}
+
+// localProviderNames returns the package-level identifiers of the container package
+// that the constructor refers to without a qualifier.
+func (c Container) localProviderNames() []string {
+	var out []string
+	for _, n := range append(slices.Clone(c.Nodes), c.Lazy.Nodes()...) {
+		p := n.Provider
+		if p == nil || p.PkgPath != c.PkgPath {
+			continue
+		}
+		if p.Kind == resolve.ProviderFunc || p.Kind == resolve.ProviderVar || p.Kind == resolve.ProviderStruct {
+			out = append(out, p.Name)
+		}
+	}
+	return out
 }
 
 type EmitInput struct {
@@ -34,12 +115,16 @@ type EmitInput struct {
 	PackageName string
 	OnError     *config.OnError
 	Containers  []Container
+	// BuildConstraint is the `//go:build` line of the generated file, if any.
+	BuildConstraint string
 }
 
 func (ei EmitInput) Append(c Container) EmitInput {
 	return EmitInput{
-		PackageName: ei.PackageName,
-		Containers:  append(ei.Containers, c),
+		PackageName:     ei.PackageName,
+		OnError:         ei.OnError,
+		Containers:      append(ei.Containers, c),
+		BuildConstraint: ei.BuildConstraint,
 	}
 }
 
@@ -56,24 +141,30 @@ func EmitContainers(in EmitInput) ([]byte, error) {
 		}
 	}
 
+	std := stdImports(in)
 	aliases := make(map[string]string)
-	importLog := in.OnError != nil && in.OnError.String() == config.OnErrorFatal.String()
 	for _, c := range in.Containers {
-		err := buildImportAliases(aliases, c.PkgPath, c.Providers, importLog)
+		err := buildImportAliases(aliases, c, std)
 		if err != nil {
 			return nil, fmt.Errorf("gen: failed to build import aliases: %v", err)
 		}
 	}
 
 	var buf bytes.Buffer
-	prints.Fprint(&buf, "// Code generated by injector. DO NOT EDIT.\n\n")
+	prints.Fprintf(&buf, "%s\n\n", GeneratedComment)
+	if in.BuildConstraint != "" {
+		prints.Fprintf(&buf, "%s\n\n", in.BuildConstraint)
+	}
 	prints.Fprintf(&buf, "package %s\n\n", in.PackageName)
 
 	imports := sortedImports(aliases)
-	if len(imports) > 0 || importLog {
+	if len(imports) > 0 || len(std) > 0 {
 		buf.WriteString("import (\n")
-		if importLog {
-			prints.Fprintf(&buf, "\t%q\n\n", "log")
+		for _, imp := range std {
+			prints.Fprintf(&buf, "\t%q\n", imp)
+		}
+		if len(std) > 0 {
+			buf.WriteString("\n")
 		}
 		for _, imp := range imports {
 			prints.Fprintf(&buf, "\t%s %q\n", aliases[imp], imp)
@@ -81,15 +172,34 @@ func EmitContainers(in EmitInput) ([]byte, error) {
 		buf.WriteString(")\n\n")
 	}
 
+	// A container has Start and Stop if any of its constructors has lifecycle components.
+	lifecycle := map[string]bool{}
 	for _, c := range in.Containers {
-		if err := writeNewFunc(&buf, c, aliases, nil); err != nil {
+		if len(c.Lifecycle) > 0 {
+			lifecycle[c.Name] = true
+		}
+	}
+
+	methods := map[string]bool{}
+	for _, c := range in.Containers {
+		if err := writeNewFunc(&buf, c, aliases, std, nil); err != nil {
 			return nil, fmt.Errorf("gen: failed to write: %v", err)
 		}
 		if in.OnError != nil {
-			if err := writeNewFunc(&buf, c, aliases, in.OnError); err != nil {
+			if err := writeNewFunc(&buf, c, aliases, std, in.OnError); err != nil {
 				return nil, fmt.Errorf("gen: failed to write must: %v", err)
 			}
 		}
+		if methods[c.Name] {
+			continue
+		}
+		methods[c.Name] = true
+		if lifecycle[c.Name] {
+			writeLifecycleMethods(&buf, c)
+		}
+		if err := writeLazyProxies(&buf, c, aliases, std); err != nil {
+			return nil, fmt.Errorf("gen: failed to write lazy proxy: %v", err)
+		}
 	}
 
 	src, err := format.Source(buf.Bytes())
@@ -99,16 +209,67 @@ func EmitContainers(in EmitInput) ([]byte, error) {
 	return src, nil
 }
 
-func writeNewFunc(buf *bytes.Buffer, c Container, aliases map[string]string, onError *config.OnError) error {
-	// Build local variable plan: typeKey -> varName
-	varByType := map[string]string{}
+// stdImports returns the standard library packages used by the generated code, sorted.
+// They are imported without an alias.
+func stdImports(in EmitInput) []string {
+	set := map[string]struct{}{}
+	for _, c := range in.Containers {
+		if in.OnError != nil && in.OnError.String() == config.OnErrorFatal.String() && c.returnsError() {
+			set["log"] = struct{}{}
+		}
+		if c.Context != nil {
+			set["context"] = struct{}{}
+		}
+		if len(c.Lifecycle) > 0 {
+			set["context"] = struct{}{}
+			set["errors"] = struct{}{}
+			set["sync"] = struct{}{}
+		}
+		if c.usesOnce() {
+			set["sync"] = struct{}{}
+		}
+	}
 
-	returnErr := slices.ContainsFunc(c.Providers, func(provider *resolve.Provider) bool {
-		return provider.ReturnError
-	}) && onError == nil
+	out := make([]string, 0, len(set))
+	for imp := range set {
+		out = append(out, imp)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func writeNewFunc(buf *bytes.Buffer, c Container, aliases map[string]string, std []string, onError *config.OnError) error {
+	// Build local variable plan: node -> varName
+	varByNode := map[*resolve.Node]string{}
+	// usedNames holds identifiers that local variables must not shadow.
+	usedNames := reservedNames(aliases, std)
+	for _, name := range c.localProviderNames() {
+		usedNames[name] = struct{}{}
+	}
+
+	returnErr := c.returnsError() && onError == nil
+	returnCleanup := slices.ContainsFunc(c.Nodes, func(n *resolve.Node) bool {
+		return n.Provider.ReturnCleanup
+	})
 
 	must := onError != nil
 
+	if returnCleanup {
+		usedNames["cleanup"] = struct{}{}
+		usedNames["cleanups"] = struct{}{}
+	}
+
+	var params []string
+	for _, n := range c.params() {
+		name, err := localName(n.Provider.Name, usedNames)
+		if err != nil {
+			return err
+		}
+		usedNames[name] = struct{}{}
+		varByNode[n] = name
+		params = append(params, name+" "+typeExpr(n.Provider.ResultType, c.PkgPath, aliases))
+	}
+
 	funcName := c.FuncName
 	doc := fmt.Sprintf("%s initializes dependencies and constructs %s.", funcName, c.Name)
 	if must {
@@ -116,111 +277,339 @@ func writeNewFunc(buf *bytes.Buffer, c Container, aliases map[string]string, onE
 		doc = fmt.Sprintf("%s initializes dependencies and constructs %s or %s on failure.", funcName, c.Name, onError.Behavior())
 	}
 	prints.Fprintf(buf, "// %s\n", doc)
+	if c.Profile != "" {
+		prints.Fprint(buf, "//\n")
+		prints.Fprintf(buf, "// Providers of the %s profile take precedence over providers without a profile.\n", c.Profile)
+	}
+	if c.Context != nil {
+		prints.Fprint(buf, "//\n")
+		prints.Fprintf(buf, "// %s is passed to every provider that accepts a context.Context.\n", varByNode[c.Context])
+	}
+	if len(c.Args) > 0 {
+		prints.Fprint(buf, "//\n")
+		prints.Fprint(buf, "// Parameters follow the declaration order of the inject:\"arg\" fields:\n")
+		for _, n := range c.Args {
+			prints.Fprintf(buf, "//   - %s: %s\n", varByNode[n], argDoc(n.Provider))
+		}
+	}
+	if returnCleanup {
+		prints.Fprint(buf, "//\n")
+		prints.Fprint(buf, "// The returned cleanup function releases resources in reverse construction order.\n")
+	}
+	if len(c.Missing) > 0 {
+		prints.Fprint(buf, "//\n")
+		prints.Fprint(buf, "// Optional dependencies without a provider are left as zero values:\n")
+		for _, m := range c.Missing {
+			prints.Fprintf(buf, "//   - %s (required by %s)\n", bindingDoc(m.Type, m.Name), m.RequiredBy)
+		}
+	}
+	lazy := c.lazyFields()
+	if len(lazy) > 0 {
+		var names []string
+		for _, f := range c.Fields {
+			if f.Resolvable() && f.Inject.Lazy {
+				names = append(names, f.Name)
+			}
+		}
+		prints.Fprint(buf, "//\n")
+		prints.Fprintf(buf, "// The lazy fields (%s) build their own dependencies on first use.\n", strings.Join(names, ", "))
+	}
 
+	results := []string{"*" + c.Name}
+	failResults := []string{"nil"}
+	if returnCleanup {
+		results = append(results, "func()")
+		failResults = append(failResults, "nil")
+	}
 	if returnErr {
-		prints.Fprintf(buf, "func %s() (*%s, error) {\n", funcName, c.Name)
+		results = append(results, "error")
+		failResults = append(failResults, "err")
+	}
+	if len(results) == 1 {
+		prints.Fprintf(buf, "func %s(%s) %s {\n", funcName, strings.Join(params, ", "), results[0])
 	} else {
-		prints.Fprintf(buf, "func %s() *%s {\n", funcName, c.Name)
+		prints.Fprintf(buf, "func %s(%s) (%s) {\n", funcName, strings.Join(params, ", "), strings.Join(results, ", "))
 	}
 
-	for _, p := range c.Providers {
-		if p == nil {
-			continue
-		}
+	if returnCleanup {
+		prints.Fprint(buf, "\tvar cleanups []func()\n")
+		prints.Fprint(buf, "\tcleanup := func() {\n")
+		prints.Fprint(buf, "\t\tfor i := len(cleanups) - 1; i >= 0; i-- {\n")
+		prints.Fprint(buf, "\t\t\tcleanups[i]()\n")
+		prints.Fprint(buf, "\t\t}\n")
+		prints.Fprint(buf, "\t}\n")
+	}
 
-		call := providerCallExpr(c.PkgPath, aliases, p)
+	// hasCleanups reports whether a cleanup has been collected so far,
+	// in which case a failure must release it before returning.
+	hasCleanups := false
 
-		var args []string
-		for _, pt := range p.Params {
-			key := typeKey(pt)
-			v, ok := varByType[key]
-			if !ok {
-				return fmt.Errorf(
-					"missing resolved value for param %s (required by %s)",
-					typeString(pt),
-					providerString(p),
-				)
+	for _, n := range c.Nodes {
+		if n == nil || n.Provider == nil {
+			continue
+		}
+		p := n.Provider
+		if p.Kind == resolve.ProviderArg {
+			// The value is a constructor parameter.
+			continue
+		}
+		if p.Kind == resolve.ProviderZero {
+			if err := writeZeroValue(buf, "\t", c, aliases, n, varByNode, usedNames); err != nil {
+				return err
 			}
-			args = append(args, v)
+			continue
 		}
 
-		resKey := typeKey(p.ResultType)
-		vname, err := varNameForResult(p.Name, varByType)
+		call, err := nodeCallExpr(c.PkgPath, aliases, n, varByNode)
 		if err != nil {
 			return err
 		}
 
+		vname, err := varNameForResult(p.Name, usedNames)
+		if err != nil {
+			return err
+		}
+		usedNames[vname] = struct{}{}
+
+		lhs := []string{vname}
+		var cname string
+		if p.ReturnCleanup {
+			cname, err = localName(vname+"Cleanup", usedNames)
+			if err != nil {
+				return err
+			}
+			usedNames[cname] = struct{}{}
+			lhs = append(lhs, cname)
+		}
+		if p.ReturnError {
+			lhs = append(lhs, "err")
+		}
+
+		prints.Fprintf(buf, "\t%s := %s\n", strings.Join(lhs, ", "), call)
 		if p.ReturnError {
-			prints.Fprintf(buf, "\t%s, err := %s(%s)\n", vname, call, strings.Join(args, ", "))
 			prints.Fprint(buf, "\tif err != nil {\n")
+			if hasCleanups {
+				prints.Fprint(buf, "\t\tcleanup()\n")
+			}
 			if must {
 				prints.Fprintf(buf, "\t\t%s(err)\n", onError.Func())
 			} else {
-				prints.Fprint(buf, "\t\treturn nil, err\n")
+				prints.Fprintf(buf, "\t\treturn %s\n", strings.Join(failResults, ", "))
 			}
 			prints.Fprint(buf, "\t}\n")
-		} else {
-			prints.Fprintf(buf, "\t%s := %s(%s)\n", vname, call, strings.Join(args, ", "))
 		}
-		varByType[resKey] = vname
+		if p.ReturnCleanup {
+			prints.Fprintf(buf, "\tcleanups = append(cleanups, %s)\n", cname)
+			hasCleanups = true
+		}
+		varByNode[n] = vname
 	}
 
-	buf.WriteString("\n\treturn &")
-	buf.WriteString(c.Name)
-	buf.WriteString("{\n")
+	getters := map[*resolve.Node]lazyGetter{}
+	for _, s := range c.Lazy.Shared {
+		if err := writeSharedLazy(buf, c, s, aliases, varByNode, usedNames, getters); err != nil {
+			return fmt.Errorf("lazy dependency %s: %w", providerString(s.Node.Provider), err)
+		}
+	}
 
+	lazyVars := map[int]string{}
+	for i := range c.Roots {
+		f, ok := lazy[i]
+		if !ok {
+			continue
+		}
+		var nodes []*resolve.Node
+		if i < len(c.Lazy.Fields) {
+			nodes = c.Lazy.Fields[i]
+		}
+		v, err := writeLazyField(buf, c, f, c.Roots[i], nodes, aliases, varByNode, usedNames, getters)
+		if err != nil {
+			return fmt.Errorf("lazy field %s: %w", f.Name, err)
+		}
+		lazyVars[i] = v
+	}
+
+	// A container with lifecycle components is registered with its hooks before it is returned.
+	var recv string
+	if len(c.Lifecycle) > 0 {
+		name, err := localName("c", usedNames)
+		if err != nil {
+			return err
+		}
+		usedNames[name] = struct{}{}
+		recv = name
+		prints.Fprintf(buf, "\n\t%s := &%s{\n", recv, c.Name)
+	} else {
+		prints.Fprintf(buf, "\n\treturn &%s{\n", c.Name)
+	}
+
+	i := 0
 	for _, f := range c.Fields {
-		if f.Name == "_" {
+		if f.Inject.Lifecycle {
+			hooks := make([]string, 0, len(c.Lifecycle))
+			for _, n := range c.Lifecycle {
+				v, ok := varByNode[n]
+				if !ok {
+					return fmt.Errorf("gen: missing resolved value for lifecycle component %s", providerString(n.Provider))
+				}
+				hooks = append(hooks, v)
+			}
+			prints.Fprintf(buf, "\t\t%s: []any{%s},\n", f.Name, strings.Join(hooks, ", "))
+			continue
+		}
+		if !f.Resolvable() {
 			continue
 		}
 
-		key := typeKey(f.Type)
-		v, ok := varByType[key]
-		if !ok {
+		var v string
+		if lv, ok := lazyVars[i]; ok {
+			v = lv
+		} else if i < len(c.Roots) {
+			v = varByNode[c.Roots[i]]
+		}
+		i++
+		if v == "" {
 			return fmt.Errorf("gen: missing resolved value for field %s (%s)", f.Name, typeString(f.Type))
 		}
 
 		prints.Fprintf(buf, "\t\t%s: %s,\n", f.Name, v)
 	}
 
+	buf.WriteString("\t}")
+	if recv != "" {
+		buf.WriteString("\n")
+		if err := writeLifecycleHooks(buf, c, recv, varByNode); err != nil {
+			return err
+		}
+		prints.Fprintf(buf, "\treturn %s", recv)
+	}
+	if returnCleanup {
+		buf.WriteString(", cleanup")
+	}
 	if returnErr {
-		buf.WriteString("\t}, nil\n")
-		buf.WriteString("}\n")
-	} else {
-		buf.WriteString("\t}\n")
-		buf.WriteString("}\n")
+		buf.WriteString(", nil")
 	}
+	buf.WriteString("\n}\n")
 
 	return nil
 }
 
-func buildImportAliases(aliases map[string]string, containerPkgPath string, providers []*resolve.Provider, importLog bool) error {
-	used := make(map[string]struct{})
-	for _, a := range aliases {
-		used[a] = struct{}{}
+// writeLifecycleHooks registers the hooks of the lifecycle components of the container recv.
+// The start hook calls the start method of each component in dependency order;
+// if one fails, the components whose start method ran are stopped in reverse order.
+// The stop hook calls the stop method of every component in reverse order.
+func writeLifecycleHooks(buf *bytes.Buffer, c Container, recv string, varByNode map[*resolve.Node]string) error {
+	typeName, varName := c.lifecycleNames()
+	vars := make([]string, len(c.Lifecycle))
+	// The hooks may shadow the context parameter of the constructor, but not a component.
+	used := map[string]struct{}{}
+	for i, n := range c.Lifecycle {
+		v, ok := varByNode[n]
+		if !ok {
+			return fmt.Errorf("gen: missing resolved value for lifecycle component %s", providerString(n.Provider))
+		}
+		vars[i] = v
+		used[v] = struct{}{}
 	}
-	if importLog {
-		used["log"] = struct{}{}
+	ctx, err := localName("ctx", used)
+	if err != nil {
+		return err
 	}
 
-	for _, p := range providers {
-		if p == nil || p.PkgPath == "" {
+	lc := c.LifecycleMethods
+	prints.Fprintf(buf, "\t%s.Store(%s, %s{\n", varName, recv, typeName)
+	prints.Fprintf(buf, "\t\tstart: func(%s context.Context) error {\n", ctx)
+	var started []string
+	for i, n := range c.Lifecycle {
+		t := n.Provider.ResultType
+		if !lc.Starts(t) {
 			continue
 		}
-		if p.PkgPath == containerPkgPath {
-			continue
+		prints.Fprintf(buf, "\t\t\tif err := %s.%s(%s); err != nil {\n", vars[i], lc.Start, ctx)
+		if len(started) == 0 {
+			prints.Fprint(buf, "\t\t\t\treturn err\n")
+		} else {
+			rollback := []string{"err"}
+			for j := len(started) - 1; j >= 0; j-- {
+				rollback = append(rollback, fmt.Sprintf("%s.%s(%s)", started[j], lc.Stop, ctx))
+			}
+			prints.Fprintf(buf, "\t\t\t\treturn errors.Join(%s)\n", strings.Join(rollback, ", "))
 		}
-
-		base := path.Base(p.PkgPath)
-		if base == "" || base == "." || base == "/" {
-			return fmt.Errorf("invalid provider package path %q for %s", p.PkgPath, providerString(p))
+		prints.Fprint(buf, "\t\t\t}\n")
+		if lc.Stops(t) {
+			started = append(started, vars[i])
 		}
-		alias := base
+	}
+	prints.Fprint(buf, "\t\t\treturn nil\n")
+	prints.Fprint(buf, "\t\t},\n")
+	prints.Fprintf(buf, "\t\tstop: func(%s context.Context) error {\n", ctx)
+	prints.Fprint(buf, "\t\t\treturn errors.Join(\n")
+	for i := len(c.Lifecycle) - 1; i >= 0; i-- {
+		if lc.Stops(c.Lifecycle[i].Provider.ResultType) {
+			prints.Fprintf(buf, "\t\t\t\t%s.%s(%s),\n", vars[i], lc.Stop, ctx)
+		}
+	}
+	prints.Fprint(buf, "\t\t\t)\n")
+	prints.Fprint(buf, "\t\t},\n")
+	prints.Fprint(buf, "\t})\n")
+	return nil
+}
 
-		if _, ok := aliases[p.PkgPath]; ok {
-			continue
+// writeLifecycleMethods writes the Start and Stop methods of a container with lifecycle components,
+// together with the registry of the hooks its constructors register.
+func writeLifecycleMethods(buf *bytes.Buffer, c Container) {
+	typeName, varName := c.lifecycleNames()
+
+	prints.Fprintf(buf, "\n// %s holds the lifecycle hooks of a constructed %s.\n", typeName, c.Name)
+	prints.Fprintf(buf, "type %s struct {\n", typeName)
+	prints.Fprint(buf, "\tstart func(context.Context) error\n")
+	prints.Fprint(buf, "\tstop  func(context.Context) error\n")
+	prints.Fprint(buf, "}\n")
+	prints.Fprintf(buf, "\n// %s maps each %s built by a generated constructor to its %s.\n", varName, c.Name, typeName)
+	prints.Fprintf(buf, "var %s sync.Map\n", varName)
+
+	prints.Fprintf(buf, "\n// Start starts the lifecycle components of %s in dependency order.\n", c.Name)
+	prints.Fprint(buf, "// If a component fails to start, the components started before it are stopped in reverse order.\n")
+	prints.Fprintf(buf, "func (c *%s) Start(ctx context.Context) error {\n", c.Name)
+	prints.Fprintf(buf, "\tif lc, ok := %s.Load(c); ok {\n", varName)
+	prints.Fprintf(buf, "\t\treturn lc.(%s).start(ctx)\n", typeName)
+	prints.Fprint(buf, "\t}\n")
+	prints.Fprint(buf, "\treturn nil\n")
+	prints.Fprint(buf, "}\n")
+
+	prints.Fprintf(buf, "\n// Stop stops the lifecycle components of %s in reverse dependency order.\n", c.Name)
+	prints.Fprint(buf, "// Every component is stopped even if some of them fail; the errors are joined.\n")
+	prints.Fprintf(buf, "func (c *%s) Stop(ctx context.Context) error {\n", c.Name)
+	prints.Fprintf(buf, "\tif lc, ok := %s.Load(c); ok {\n", varName)
+	prints.Fprintf(buf, "\t\treturn lc.(%s).stop(ctx)\n", typeName)
+	prints.Fprint(buf, "\t}\n")
+	prints.Fprint(buf, "\treturn nil\n")
+	prints.Fprint(buf, "}\n")
+}
+
+func buildImportAliases(aliases map[string]string, c Container, std []string) error {
+	used := make(map[string]struct{})
+	for _, a := range aliases {
+		used[a] = struct{}{}
+	}
+	stdSet := make(map[string]struct{}, len(std))
+	for _, imp := range std {
+		stdSet[imp] = struct{}{}
+		used[path.Base(imp)] = struct{}{}
+	}
+
+	add := func(pkgPath, base string) {
+		if pkgPath == c.PkgPath {
+			return
+		}
+		if _, ok := stdSet[pkgPath]; ok {
+			return
+		}
+		if _, ok := aliases[pkgPath]; ok {
+			return
 		}
 
+		alias := base
 		if _, ok := used[alias]; ok {
 			for i := 2; ; i++ {
 				try := fmt.Sprintf("%s%d", base, i)
@@ -231,13 +620,99 @@ func buildImportAliases(aliases map[string]string, containerPkgPath string, prov
 			}
 		}
 
-		aliases[p.PkgPath] = alias
+		aliases[pkgPath] = alias
 		used[alias] = struct{}{}
 	}
 
+	nodes := append(slices.Clone(c.Nodes), c.Lazy.Nodes()...)
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		p := n.Provider
+		if p == nil || p.PkgPath == "" || p.Kind == resolve.ProviderMethod || p.Kind == resolve.ProviderField {
+			// Methods and fields are selected from a value without a package qualifier.
+			continue
+		}
+
+		base := path.Base(p.PkgPath)
+		if base == "" || base == "." || base == "/" {
+			return fmt.Errorf("invalid provider package path %q for %s", p.PkgPath, providerString(p))
+		}
+		add(p.PkgPath, base)
+	}
+
+	// Types spelled out in the generated code (e.g. constructor parameters, groups, type arguments and lazy fields).
+	var spelled []types.Type
+	for _, n := range c.params() {
+		spelled = append(spelled, n.Provider.ResultType)
+	}
+	for _, n := range nodes {
+		if n == nil || n.Provider == nil {
+			continue
+		}
+		if k := n.Provider.Kind; k == resolve.ProviderGroup || k == resolve.ProviderZero {
+			spelled = append(spelled, n.Provider.ResultType)
+		}
+		spelled = append(spelled, n.Provider.TypeArgs...)
+	}
+	for _, s := range c.Lazy.Shared {
+		spelled = append(spelled, s.Node.Provider.ResultType)
+	}
+	for _, f := range c.lazyFields() {
+		spelled = append(spelled, f.Type)
+		if f.Inject.Proxy {
+			spelled = append(spelled, proxyMethodTypes(f.Type)...)
+		}
+	}
+	for _, t := range spelled {
+		for _, pkg := range typePackages(t) {
+			add(pkg.Path(), pkg.Name())
+		}
+	}
+
 	return nil
 }
 
+// typePackages returns the packages referenced by t, in order of appearance.
+func typePackages(t types.Type) []*types.Package {
+	var out []*types.Package
+	types.TypeString(t, func(p *types.Package) string {
+		if p != nil {
+			out = append(out, p)
+		}
+		return ""
+	})
+	return out
+}
+
+// typeExpr formats t as Go source using the file's import aliases.
+func typeExpr(t types.Type, containerPkgPath string, aliases map[string]string) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == nil || p.Path() == containerPkgPath {
+			return ""
+		}
+		if alias, ok := aliases[p.Path()]; ok {
+			return alias
+		}
+		return p.Name()
+	})
+}
+
+// argDoc describes a constructor parameter in the generated doc comment.
+func argDoc(p *resolve.Provider) string {
+	return bindingDoc(p.ResultType, p.Qualifier)
+}
+
+// bindingDoc describes a binding in the generated doc comment.
+func bindingDoc(t types.Type, name string) string {
+	s := typeString(t)
+	if name != "" {
+		s += fmt.Sprintf(" (name: %s)", name)
+	}
+	return s
+}
+
 func sortedImports(aliases map[string]string) []string {
 	if len(aliases) == 0 {
 		return nil
@@ -250,6 +725,93 @@ func sortedImports(aliases map[string]string) []string {
 	return out
 }
 
+// writeZeroValue declares the zero value of a missing optional dependency.
+func writeZeroValue(
+	buf *bytes.Buffer,
+	indent string,
+	c Container,
+	aliases map[string]string,
+	n *resolve.Node,
+	varByNode map[*resolve.Node]string,
+	usedNames map[string]struct{},
+) error {
+	vname, err := localName(n.Provider.Name, usedNames)
+	if err != nil {
+		return err
+	}
+	usedNames[vname] = struct{}{}
+	prints.Fprintf(buf, "%svar %s %s\n", indent, vname, typeExpr(n.Provider.ResultType, c.PkgPath, aliases))
+	varByNode[n] = vname
+	return nil
+}
+
+// nodeCallExpr formats the provider call of n, passing the variables of its dependencies.
+func nodeCallExpr(containerPkgPath string, aliases map[string]string, n *resolve.Node, varByNode map[*resolve.Node]string) (string, error) {
+	p := n.Provider
+	var args []string
+	for i, pt := range p.Params {
+		var v string
+		if i < len(n.Deps) {
+			v = varByNode[n.Deps[i]]
+		}
+		if v == "" {
+			return "", fmt.Errorf(
+				"missing resolved value for param %s (required by %s)",
+				typeString(pt),
+				providerString(p),
+			)
+		}
+		args = append(args, v)
+	}
+	if p.Kind == resolve.ProviderGroup {
+		t := typeExpr(p.ResultType, containerPkgPath, aliases)
+		if len(args) == 0 {
+			return fmt.Sprintf("%s(nil)", t), nil
+		}
+		if len(p.Keys) > 0 {
+			for i := range args {
+				args[i] = fmt.Sprintf("%q: %s", p.Keys[i], args[i])
+			}
+		}
+		return fmt.Sprintf("%s{%s}", t, strings.Join(args, ", ")), nil
+	}
+	if p.Kind == resolve.ProviderMethod {
+		// The module value is the first argument.
+		return fmt.Sprintf("%s.%s(%s)", args[0], memberName(p), strings.Join(args[1:], ", ")), nil
+	}
+	if p.Kind == resolve.ProviderField {
+		return fmt.Sprintf("%s.%s", args[0], memberName(p)), nil
+	}
+	if p.Kind == resolve.ProviderVar {
+		return providerCallExpr(containerPkgPath, aliases, p), nil
+	}
+	if p.Kind == resolve.ProviderStruct {
+		for i := range args {
+			args[i] = p.Fields[i] + ": " + args[i]
+		}
+		lit := fmt.Sprintf("%s{%s}", providerCallExpr(containerPkgPath, aliases, p), strings.Join(args, ", "))
+		if isPointer(types.Unalias(p.ResultType)) {
+			lit = "&" + lit
+		}
+		return lit, nil
+	}
+	callee := providerCallExpr(containerPkgPath, aliases, p)
+	if len(p.TypeArgs) > 0 {
+		targs := make([]string, len(p.TypeArgs))
+		for i, t := range p.TypeArgs {
+			targs[i] = typeExpr(t, containerPkgPath, aliases)
+		}
+		callee += "[" + strings.Join(targs, ", ") + "]"
+	}
+	return fmt.Sprintf("%s(%s)", callee, strings.Join(args, ", ")), nil
+}
+
+// memberName returns the method name of a ProviderMethod or the field name of a ProviderField,
+// without the type name.
+func memberName(p *resolve.Provider) string {
+	return p.Name[strings.LastIndexByte(p.Name, '.')+1:]
+}
+
 func providerCallExpr(containerPkgPath string, aliases map[string]string, p *resolve.Provider) string {
 	if p == nil {
 		return ""
@@ -264,21 +826,40 @@ func providerCallExpr(containerPkgPath string, aliases map[string]string, p *res
 	return alias + "." + p.Name
 }
 
-func varNameForResult(providerName string, existing map[string]string) (string, error) {
+// reservedNames returns the identifiers that generated local variables must avoid:
+// import aliases, standard library imports, and the err variable.
+func reservedNames(aliases map[string]string, std []string) map[string]struct{} {
+	used := map[string]struct{}{
+		"err": {},
+	}
+	for _, a := range aliases {
+		used[a] = struct{}{}
+	}
+	for _, imp := range std {
+		used[path.Base(imp)] = struct{}{}
+	}
+	return used
+}
+
+func varNameForResult(providerName string, used map[string]struct{}) (string, error) {
 	base := providerName
+	if i := strings.LastIndexByte(base, '.'); i >= 0 {
+		// Method providers are qualified by the module type name.
+		base = base[i+1:]
+	}
 	if strings.HasPrefix(base, "New") && len(base) > 3 {
 		base = base[3:]
 	}
-	base = lowerFirst(base)
+	return localName(lowerFirst(base), used)
+}
+
+// localName returns base, or base with the smallest numeric suffix, that is not in used.
+func localName(base string, used map[string]struct{}) (string, error) {
 	if base == "" {
 		return "", errors.New("empty variable name")
 	}
 
-	used := map[string]struct{}{}
-	for _, v := range existing {
-		used[v] = struct{}{}
-	}
-	if _, ok := used[base]; !ok {
+	if _, ok := used[base]; !ok && !token.IsKeyword(base) {
 		return base, nil
 	}
 	for i := 2; ; i++ {
@@ -296,15 +877,6 @@ func lowerFirst(s string) string {
 	return strings.ToLower(s[:1]) + s[1:]
 }
 
-func typeKey(t types.Type) string {
-	return types.TypeString(t, func(p *types.Package) string {
-		if p == nil {
-			return ""
-		}
-		return p.Path()
-	})
-}
-
 func typeString(t types.Type) string {
 	return types.TypeString(t, func(p *types.Package) string {
 		if p == nil {
@@ -318,6 +890,9 @@ func providerString(p *resolve.Provider) string {
 	if p == nil {
 		return "<nil>"
 	}
+	if p.Kind == resolve.ProviderGroup {
+		return p.NameWithPkg
+	}
 	if p.PkgPath == "" {
 		return p.Name
 	}
@@ -0,0 +1,146 @@
+package gen
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/mickamy/injector/internal/resolve"
+)
+
+const cleanupSrc = `package app
+
+type DB struct{}
+type Cache struct{}
+type Server struct{}
+
+func NewDB() (*DB, func(), error) { return &DB{}, func() {}, nil }
+func NewCache(*DB) (*Cache, func()) { return &Cache{}, func() {} }
+func NewServer(*Cache) (*Server, error) { return &Server{}, nil }
+`
+
+func TestEmitContainersCleanup(t *testing.T) {
+	pkg := checkSource(t, cleanupSrc)
+
+	tests := []struct {
+		name      string
+		providers []string
+		field     string
+		want      []string
+	}{
+		{
+			name:      "cleanups run in reverse construction order",
+			providers: []string{"NewDB", "NewCache"},
+			field:     "*Cache",
+			want: []string{
+				"func NewApp() (*App, func(), error) {",
+				"var cleanups []func()",
+				"cleanup := func() {",
+				"for i := len(cleanups) - 1; i >= 0; i-- {",
+				"cleanups[i]()",
+				"dB, dBCleanup, err := NewDB()",
+				"if err != nil {",
+				"return nil, nil, err",
+				"cleanups = append(cleanups, dBCleanup)",
+				"cache, cacheCleanup := NewCache(dB)",
+				"cleanups = append(cleanups, cacheCleanup)",
+				"}, cleanup, nil",
+			},
+		},
+		{
+			name:      "a failure releases what was built before it",
+			providers: []string{"NewDB", "NewCache", "NewServer"},
+			field:     "*Server",
+			want: []string{
+				"cleanups = append(cleanups, cacheCleanup)",
+				"server, err := NewServer(cache)",
+				"if err != nil {",
+				"cleanup()",
+				"return nil, nil, err",
+				"}, cleanup, nil",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := []resolve.ContainerField{field(t, pkg, "Value", tt.field, resolve.InjectTag{})}
+			checkLines(t, emitApp(t, fields, funcProviders(t, pkg, tt.providers...), resolve.Options{}), tt.want)
+		})
+	}
+}
+
+const lifecycleSrc = `package app
+
+import "context"
+
+type DB struct{}
+type Cache struct{}
+type Server struct{}
+
+func NewDB() *DB { return &DB{} }
+func NewCache(*DB) *Cache { return &Cache{} }
+func NewServer(*Cache) *Server { return &Server{} }
+
+func (*DB) Start(context.Context) error { return nil }
+func (*DB) Stop(context.Context) error { return nil }
+func (*Cache) Stop(context.Context) error { return nil }
+func (*Server) Start(context.Context) error { return nil }
+func (*Server) Stop(context.Context) error { return nil }
+`
+
+func TestEmitContainersLifecycle(t *testing.T) {
+	pkg := checkSource(t, lifecycleSrc)
+	providers := funcProviders(t, pkg, "NewDB", "NewCache", "NewServer")
+
+	tests := []struct {
+		name   string
+		fields []resolve.ContainerField
+		want   []string
+	}{
+		{
+			name:   "hooks are registered without a lifecycle field",
+			fields: []resolve.ContainerField{field(t, pkg, "Server", "*Server", resolve.InjectTag{})},
+			want: []string{
+				"c := &App{",
+				"Server: server,",
+				"appLifecycles.Store(c, appLifecycle{",
+				"start: func(ctx context.Context) error {",
+				"if err := dB.Start(ctx); err != nil {",
+				"return err",
+				"if err := server.Start(ctx); err != nil {",
+				"return errors.Join(err, dB.Stop(ctx))",
+				"return nil",
+				"stop: func(ctx context.Context) error {",
+				"return errors.Join(",
+				"server.Stop(ctx),",
+				"cache.Stop(ctx),",
+				"dB.Stop(ctx),",
+				"return c",
+				"var appLifecycles sync.Map",
+				"func (c *App) Start(ctx context.Context) error {",
+				"if lc, ok := appLifecycles.Load(c); ok {",
+				"return lc.(appLifecycle).start(ctx)",
+				"func (c *App) Stop(ctx context.Context) error {",
+				"return lc.(appLifecycle).stop(ctx)",
+			},
+		},
+		{
+			name: "the lifecycle field receives the components",
+			fields: []resolve.ContainerField{
+				field(t, pkg, "Server", "*Server", resolve.InjectTag{}),
+				{Name: "hooks", Type: types.NewSlice(types.Universe.Lookup("any").Type()), Inject: resolve.InjectTag{Lifecycle: true}},
+			},
+			want: []string{
+				"c := &App{",
+				"hooks:  []any{dB, cache, server},",
+				"appLifecycles.Store(c, appLifecycle{",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkLines(t, emitApp(t, tt.fields, providers, resolve.Options{}), tt.want)
+		})
+	}
+}
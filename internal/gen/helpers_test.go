@@ -0,0 +1,133 @@
+package gen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/mickamy/injector/internal/resolve"
+)
+
+const testPkgPath = "example.com/app"
+
+// checkSource type-checks src as the package example.com/app.
+func checkSource(t *testing.T, src string) *types.Package {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "app.go", src, 0)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check(testPkgPath, fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	return pkg
+}
+
+// funcProviders returns the providers of the functions names in pkg.
+func funcProviders(t *testing.T, pkg *types.Package, names ...string) []*resolve.Provider {
+	t.Helper()
+
+	out := make([]*resolve.Provider, 0, len(names))
+	for _, name := range names {
+		fn, ok := pkg.Scope().Lookup(name).(*types.Func)
+		if !ok {
+			t.Fatalf("func %s not found", name)
+		}
+		sig := fn.Type().(*types.Signature)
+		p := &resolve.Provider{
+			PkgPath:     testPkgPath,
+			Name:        name,
+			NameWithPkg: testPkgPath + "." + name,
+			ResultType:  sig.Results().At(0).Type(),
+		}
+		for i := 1; i < sig.Results().Len(); i++ {
+			switch sig.Results().At(i).Type().String() {
+			case "error":
+				p.ReturnError = true
+			case "func()":
+				p.ReturnCleanup = true
+			}
+		}
+		for i := 0; i < sig.Params().Len(); i++ {
+			p.Params = append(p.Params, sig.Params().At(i).Type())
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// field returns a container field of the type named by expr in pkg, optionally prefixed with "*".
+func field(t *testing.T, pkg *types.Package, name, expr string, tag resolve.InjectTag) resolve.ContainerField {
+	t.Helper()
+
+	obj, ok := pkg.Scope().Lookup(strings.TrimPrefix(expr, "*")).(*types.TypeName)
+	if !ok {
+		t.Fatalf("type %s not found", expr)
+	}
+	typ := obj.Type()
+	if strings.HasPrefix(expr, "*") {
+		typ = types.NewPointer(typ)
+	}
+	return resolve.ContainerField{Name: name, Type: typ, Inject: tag}
+}
+
+// emitApp resolves fields with providers and opts as the container App of example.com/app
+// and returns the generated file. Components are managed by the default Start and Stop methods.
+func emitApp(t *testing.T, fields []resolve.ContainerField, providers []*resolve.Provider, opts resolve.Options) string {
+	t.Helper()
+
+	g, err := resolve.BuildGraph(fields, providers, opts)
+	if err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+	nodes, err := resolve.OrderNodes(g)
+	if err != nil {
+		t.Fatalf("OrderNodes: %v", err)
+	}
+	lc := resolve.Lifecycle{Start: "Start", Stop: "Stop"}
+	src, err := EmitContainers(EmitInput{
+		PackageName: "app",
+		Containers: []Container{{
+			Name:             "App",
+			Fields:           fields,
+			Roots:            g.Roots,
+			Nodes:            nodes,
+			Args:             g.Args,
+			Context:          g.Context,
+			Lifecycle:        resolve.LifecycleNodes(nodes, lc),
+			LifecycleMethods: lc,
+			PkgPath:          testPkgPath,
+			FuncName:         "NewApp",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("EmitContainers: %v\n%s", err, src)
+	}
+	return string(src)
+}
+
+// checkLines reports the lines of want missing from src, which must contain them in order, ignoring indentation.
+func checkLines(t *testing.T, src string, want []string) {
+	t.Helper()
+
+	lines := strings.Split(src, "\n")
+	i := 0
+	for _, w := range want {
+		for i < len(lines) && strings.TrimSpace(lines[i]) != w {
+			i++
+		}
+		if i == len(lines) {
+			t.Errorf("missing line %q in order in:\n%s", w, src)
+			return
+		}
+		i++
+	}
+}
@@ -0,0 +1,360 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"slices"
+	"strings"
+
+	"github.com/mickamy/injector/internal/prints"
+	"github.com/mickamy/injector/internal/resolve"
+)
+
+// lazyGetter is the getter of a node that several lazy fields depend on.
+type lazyGetter struct {
+	name string
+	// errProvider is a provider built by the getter that returns an error, if any.
+	errProvider *resolve.Provider
+}
+
+// writeSharedLazy writes the getter of a node that several lazy fields depend on,
+// so the node is built on the first call of any of them rather than by the constructor.
+// The getters of the shared nodes it depends on must already be in getters.
+func writeSharedLazy(
+	buf *bytes.Buffer,
+	c Container,
+	s resolve.SharedLazy,
+	aliases map[string]string,
+	varByNode map[*resolve.Node]string,
+	usedNames map[string]struct{},
+	getters map[*resolve.Node]lazyGetter,
+) error {
+	errProvider, err := lazyErrProvider(s.Node, s.Nodes, getters)
+	if err != nil {
+		return err
+	}
+
+	base, err := varNameForResult(s.Node.Provider.Name, map[string]struct{}{})
+	if err != nil {
+		return err
+	}
+	name, err := localName("get"+strings.ToUpper(base[:1])+base[1:], usedNames)
+	if err != nil {
+		return err
+	}
+	usedNames[name] = struct{}{}
+
+	tExpr := typeExpr(s.Node.Provider.ResultType, c.PkgPath, aliases)
+	withErr := errProvider != nil
+	if withErr {
+		prints.Fprintf(buf, "\t%s := sync.OnceValues(func() (%s, error) {\n", name, tExpr)
+	} else {
+		prints.Fprintf(buf, "\t%s := sync.OnceValue(func() %s {\n", name, tExpr)
+	}
+	v, err := writeLazyBody(buf, c, s.Node, s.Nodes, tExpr, aliases, varByNode, usedNames, getters)
+	if err != nil {
+		return err
+	}
+	if withErr {
+		prints.Fprintf(buf, "\t\treturn %s, nil\n", v)
+	} else {
+		prints.Fprintf(buf, "\t\treturn %s\n", v)
+	}
+	prints.Fprint(buf, "\t})\n")
+
+	getters[s.Node] = lazyGetter{name: name, errProvider: errProvider}
+	return nil
+}
+
+// writeLazyField writes the getter of a lazy field and returns the expression assigned to the field.
+//
+// The getter builds nodes, the private subgraph of the field, on its first call through
+// sync.OnceValue(s), so concurrent callers share a single instance.
+// Dependencies built by the constructor are captured from the enclosing function,
+// and those shared with other lazy fields are returned by their getters.
+func writeLazyField(
+	buf *bytes.Buffer,
+	c Container,
+	f resolve.ContainerField,
+	root *resolve.Node,
+	nodes []*resolve.Node,
+	aliases map[string]string,
+	varByNode map[*resolve.Node]string,
+	usedNames map[string]struct{},
+	getters map[*resolve.Node]lazyGetter,
+) (string, error) {
+	target, withErr, err := resolve.LazyTarget(f)
+	if err != nil {
+		return "", err
+	}
+	errProvider, err := lazyErrProvider(root, nodes, getters)
+	if err != nil {
+		return "", err
+	}
+	if errProvider != nil && !withErr {
+		if f.Inject.Proxy {
+			return "", fmt.Errorf("provider %s returns an error, which lazy proxies cannot report", providerString(errProvider))
+		}
+		return "", fmt.Errorf("provider %s returns an error; declare the field as func() (T, error)", providerString(errProvider))
+	}
+
+	if g, ok := getters[root]; ok && len(nodes) == 0 && !f.Inject.Proxy &&
+		withErr == (g.errProvider != nil) && types.Identical(target, root.Provider.ResultType) {
+		// The shared getter is the getter of the field.
+		return g.name, nil
+	}
+
+	base := lowerFirst(f.Name)
+	if f.Inject.Proxy {
+		base += "Proxy"
+	}
+	name, err := localName(base, usedNames)
+	if err != nil {
+		return "", err
+	}
+	usedNames[name] = struct{}{}
+
+	tExpr := typeExpr(target, c.PkgPath, aliases)
+	getter := fmt.Sprintf("func() %s", tExpr)
+	once := "sync.OnceValue"
+	if withErr {
+		getter = fmt.Sprintf("func() (%s, error)", tExpr)
+		once = "sync.OnceValues"
+	}
+
+	var open, closing string
+	switch {
+	case f.Inject.Proxy && len(nodes) == 0:
+		open = fmt.Sprintf("\t%s := &%s{get: %s {\n", name, proxyTypeName(c, f), getter)
+		closing = "\t}}\n"
+	case f.Inject.Proxy:
+		open = fmt.Sprintf("\t%s := &%s{get: %s(%s {\n", name, proxyTypeName(c, f), once, getter)
+		closing = "\t})}\n"
+	case len(nodes) == 0:
+		// Everything is already built by the constructor or by shared getters.
+		open = fmt.Sprintf("\t%s := %s {\n", name, getter)
+		closing = "\t}\n"
+	default:
+		open = fmt.Sprintf("\t%s := %s(%s {\n", name, once, getter)
+		closing = "\t})\n"
+	}
+
+	prints.Fprint(buf, open)
+	v, err := writeLazyBody(buf, c, root, nodes, tExpr, aliases, varByNode, usedNames, getters)
+	if err != nil {
+		return "", err
+	}
+	if withErr {
+		prints.Fprintf(buf, "\t\treturn %s, nil\n", v)
+	} else {
+		prints.Fprintf(buf, "\t\treturn %s\n", v)
+	}
+	prints.Fprint(buf, closing)
+
+	return name, nil
+}
+
+// lazyErrProvider returns a provider returning an error that a getter building nodes for root calls,
+// directly or through a shared getter, if any.
+// Providers returning a cleanup function are rejected, since nothing would call it.
+func lazyErrProvider(root *resolve.Node, nodes []*resolve.Node, getters map[*resolve.Node]lazyGetter) (*resolve.Provider, error) {
+	var out *resolve.Provider
+	for _, n := range nodes {
+		p := n.Provider
+		if p.ReturnCleanup {
+			return nil, fmt.Errorf("provider %s returns a cleanup function, which lazy fields do not support", providerString(p))
+		}
+		if p.ReturnError && out == nil {
+			out = p
+		}
+	}
+	for _, d := range sharedDeps(root, nodes, getters) {
+		if out == nil {
+			out = getters[d].errProvider
+		}
+	}
+	return out, nil
+}
+
+// sharedDeps returns the nodes with a shared getter that a getter building nodes for root needs, in call order.
+func sharedDeps(root *resolve.Node, nodes []*resolve.Node, getters map[*resolve.Node]lazyGetter) []*resolve.Node {
+	var out []*resolve.Node
+	add := func(n *resolve.Node) {
+		if _, ok := getters[n]; ok && !slices.Contains(out, n) {
+			out = append(out, n)
+		}
+	}
+	for _, n := range nodes {
+		for _, d := range n.Deps {
+			add(d)
+		}
+	}
+	// A root with a shared getter is not built by the getter of a field.
+	add(root)
+	return out
+}
+
+// writeLazyBody writes the statements of a getter returning tExpr, which build nodes
+// after calling the shared getters they depend on, and returns the variable holding root.
+func writeLazyBody(
+	buf *bytes.Buffer,
+	c Container,
+	root *resolve.Node,
+	nodes []*resolve.Node,
+	tExpr string,
+	aliases map[string]string,
+	varByNode map[*resolve.Node]string,
+	usedNames map[string]struct{},
+	getters map[*resolve.Node]lazyGetter,
+) (string, error) {
+	for _, d := range sharedDeps(root, nodes, getters) {
+		g := getters[d]
+		vname, err := varNameForResult(d.Provider.Name, usedNames)
+		if err != nil {
+			return "", err
+		}
+		usedNames[vname] = struct{}{}
+
+		if g.errProvider != nil {
+			prints.Fprintf(buf, "\t\t%s, err := %s()\n", vname, g.name)
+			writeLazyErrReturn(buf, tExpr)
+		} else {
+			prints.Fprintf(buf, "\t\t%s := %s()\n", vname, g.name)
+		}
+		varByNode[d] = vname
+	}
+
+	for _, n := range nodes {
+		p := n.Provider
+		if p.Kind == resolve.ProviderZero {
+			if err := writeZeroValue(buf, "\t\t", c, aliases, n, varByNode, usedNames); err != nil {
+				return "", err
+			}
+			continue
+		}
+		call, err := nodeCallExpr(c.PkgPath, aliases, n, varByNode)
+		if err != nil {
+			return "", err
+		}
+
+		vname, err := varNameForResult(p.Name, usedNames)
+		if err != nil {
+			return "", err
+		}
+		usedNames[vname] = struct{}{}
+
+		if p.ReturnError {
+			prints.Fprintf(buf, "\t\t%s, err := %s\n", vname, call)
+			writeLazyErrReturn(buf, tExpr)
+		} else {
+			prints.Fprintf(buf, "\t\t%s := %s\n", vname, call)
+		}
+		varByNode[n] = vname
+	}
+
+	v, ok := varByNode[root]
+	if !ok {
+		return "", fmt.Errorf("missing resolved value for %s", providerString(root.Provider))
+	}
+	return v, nil
+}
+
+// writeLazyErrReturn writes the check returning err from a getter returning tExpr.
+func writeLazyErrReturn(buf *bytes.Buffer, tExpr string) {
+	prints.Fprint(buf, "\t\tif err != nil {\n")
+	prints.Fprintf(buf, "\t\t\tvar zero %s\n", tExpr)
+	prints.Fprint(buf, "\t\t\treturn zero, err\n")
+	prints.Fprint(buf, "\t\t}\n")
+}
+
+// writeLazyProxies writes a proxy type for each `inject:"lazy:proxy"` field of c.
+// The proxy implements the field's interface by forwarding every call
+// to the implementation returned by its getter.
+func writeLazyProxies(buf *bytes.Buffer, c Container, aliases map[string]string, std []string) error {
+	for _, f := range c.Fields {
+		if !f.Resolvable() || !f.Inject.Proxy {
+			continue
+		}
+
+		name := proxyTypeName(c, f)
+		iface := typeExpr(f.Type, c.PkgPath, aliases)
+		used := reservedNames(aliases, std)
+		recv, err := localName("p", used)
+		if err != nil {
+			return err
+		}
+		used[recv] = struct{}{}
+
+		prints.Fprintf(buf, "\n// %s implements %s for the lazy field %s.%s.\n", name, iface, c.Name, f.Name)
+		prints.Fprint(buf, "// The implementation is built on the first method call.\n")
+		prints.Fprintf(buf, "type %s struct {\n", name)
+		prints.Fprintf(buf, "\tget func() %s\n", iface)
+		prints.Fprint(buf, "}\n")
+
+		ms := types.NewMethodSet(f.Type)
+		for i := 0; i < ms.Len(); i++ {
+			m := ms.At(i).Obj()
+			if !m.Exported() {
+				return fmt.Errorf("field %s: cannot proxy unexported method %s of %s", f.Name, m.Name(), typeString(f.Type))
+			}
+			sig := m.Type().(*types.Signature)
+
+			var params, args []string
+			for j := 0; j < sig.Params().Len(); j++ {
+				pname, err := localName(fmt.Sprintf("a%d", j), used)
+				if err != nil {
+					return err
+				}
+				t := sig.Params().At(j).Type()
+				if sig.Variadic() && j == sig.Params().Len()-1 {
+					elem := t.(*types.Slice).Elem()
+					params = append(params, fmt.Sprintf("%s ...%s", pname, typeExpr(elem, c.PkgPath, aliases)))
+					args = append(args, pname+"...")
+					continue
+				}
+				params = append(params, fmt.Sprintf("%s %s", pname, typeExpr(t, c.PkgPath, aliases)))
+				args = append(args, pname)
+			}
+
+			var results []string
+			for j := 0; j < sig.Results().Len(); j++ {
+				results = append(results, typeExpr(sig.Results().At(j).Type(), c.PkgPath, aliases))
+			}
+			var res string
+			switch len(results) {
+			case 0:
+			case 1:
+				res = " " + results[0]
+			default:
+				res = fmt.Sprintf(" (%s)", strings.Join(results, ", "))
+			}
+
+			call := fmt.Sprintf("%s.get().%s(%s)", recv, m.Name(), strings.Join(args, ", "))
+			prints.Fprintf(buf, "\nfunc (%s *%s) %s(%s)%s {\n", recv, name, m.Name(), strings.Join(params, ", "), res)
+			if len(results) == 0 {
+				prints.Fprintf(buf, "\t%s\n", call)
+			} else {
+				prints.Fprintf(buf, "\treturn %s\n", call)
+			}
+			prints.Fprint(buf, "}\n")
+		}
+	}
+	return nil
+}
+
+// proxyTypeName returns the name of the proxy type generated for the lazy field f of c.
+func proxyTypeName(c Container, f resolve.ContainerField) string {
+	return lowerFirst(c.Name) + f.Name + "Proxy"
+}
+
+// proxyMethodTypes returns the signatures of the methods of iface,
+// whose types are spelled out by the generated proxy.
+func proxyMethodTypes(iface types.Type) []types.Type {
+	ms := types.NewMethodSet(iface)
+	out := make([]types.Type, 0, ms.Len())
+	for i := 0; i < ms.Len(); i++ {
+		out = append(out, ms.At(i).Obj().Type())
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package resolve
+
+import (
+	"slices"
+	"testing"
+)
+
+const argsSrc = `package app
+
+type Config struct{}
+type Server struct{}
+
+func NewConfig() *Config { return &Config{} }
+func NewServer(*Config) *Server { return &Server{} }
+`
+
+func TestBuildGraphArgs(t *testing.T) {
+	pkg := checkSource(t, argsSrc)
+
+	tests := []struct {
+		name     string
+		fields   []fieldSpec
+		want     [][]string
+		wantArgs []string
+		wantErr  string
+	}{
+		{
+			name: "arg takes precedence over providers",
+			fields: []fieldSpec{
+				{name: "Config", typ: "*Config", tag: InjectTag{Arg: true}},
+				{name: "Server", typ: "*Server"},
+			},
+			want:     [][]string{{"config"}, {"NewServer", "config"}},
+			wantArgs: []string{"config"},
+		},
+		{
+			name: "blank arg is named after its type",
+			fields: []fieldSpec{
+				{name: "_", typ: "*Config", tag: InjectTag{Arg: true}},
+				{name: "Server", typ: "*Server"},
+			},
+			want:     [][]string{{"NewServer", "config"}},
+			wantArgs: []string{"config"},
+		},
+		{
+			name: "blank qualified arg is named after its qualifier",
+			fields: []fieldSpec{
+				{name: "_", typ: "*Config", tag: InjectTag{Arg: true, Name: "Base"}},
+				{name: "Server", typ: "*Server"},
+			},
+			want:     [][]string{{"NewServer", "NewConfig"}},
+			wantArgs: []string{"base"},
+		},
+		{
+			name: "arg conflicts with an override",
+			fields: []fieldSpec{
+				{name: "_", typ: "*Config", tag: InjectTag{Provider: "app.NewConfig"}},
+				{name: "Config", typ: "*Config", tag: InjectTag{Arg: true}},
+			},
+			wantErr: "arg config conflicts with example.com/app.NewConfig",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := funcProviders(t, pkg, nil, "NewConfig", "NewServer")
+			g, err := buildGraph(containerFields(t, pkg, tt.fields...), providers, Options{})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := rootNames(g); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("roots = %v, want %v", got, tt.want)
+			}
+			if got := nodeNames(g.Args); !slices.Equal(got, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", got, tt.wantArgs)
+			}
+			for _, a := range g.Args {
+				if a.Provider.Kind != ProviderArg {
+					t.Errorf("arg %s has kind %v", a.Provider.Name, a.Provider.Kind)
+				}
+			}
+		})
+	}
+}
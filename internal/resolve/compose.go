@@ -0,0 +1,123 @@
+package resolve
+
+import (
+	"fmt"
+	"go/types"
+	"slices"
+	"strings"
+
+	"github.com/mickamy/injector/internal/scan"
+)
+
+// ContainerProviders returns the providers that let other containers depend on c:
+// its generated constructor funcName, which returns *C, and a provider for each named field.
+//
+// Field providers are only candidates in containers that declare a field of type *C
+// with the compose directive, where they take precedence over the other providers of their types,
+// so the values built by c are reused rather than built twice.
+// Arg and lazy fields are not reused.
+//
+// The constructor takes a context.Context first if context is set, then the arg fields of c.
+// Its ReturnError and ReturnCleanup depend on the graph of c; set them with SetContainerResults.
+func ContainerProviders(c scan.ContainerSpec, fields []ContainerField, funcName string, context bool) []*Provider {
+	if c.Type == nil {
+		return nil
+	}
+	ptr := types.NewPointer(c.Type)
+
+	ctor := &Provider{
+		Kind:        ProviderFunc,
+		PkgPath:     c.PkgPath,
+		Name:        funcName,
+		NameWithPkg: strings.Join([]string{c.PkgPath, funcName}, "."),
+		ResultType:  ptr,
+		Container:   true,
+		Position:    c.Position,
+	}
+	if context {
+		ctor.Params = append(ctor.Params, syntheticContextType())
+		ctor.ParamTags = append(ctor.ParamTags, InjectTag{})
+	}
+	for _, f := range fields {
+		if f.Inject.Arg {
+			ctor.Params = append(ctor.Params, f.Type)
+			ctor.ParamTags = append(ctor.ParamTags, InjectTag{Name: f.Inject.Name})
+		}
+	}
+
+	out := []*Provider{ctor}
+	for _, f := range fields {
+		if !f.Resolvable() || f.Inject.Arg || f.Inject.Lazy {
+			continue
+		}
+		name := c.Name + "." + f.Name
+		out = append(out, &Provider{
+			Kind:        ProviderField,
+			PkgPath:     c.PkgPath,
+			Name:        name,
+			NameWithPkg: strings.Join([]string{c.PkgPath, name}, "."),
+			ResultType:  f.Type,
+			Params:      []types.Type{ptr},
+			ParamTags:   []InjectTag{{}},
+			Qualifier:   f.Inject.Name,
+			Container:   true,
+			Position:    c.Position,
+		})
+	}
+	return out
+}
+
+// SetContainerResults sets the results of the constructor of a container from its ordered nodes,
+// as the generator declares them.
+func SetContainerResults(ctor *Provider, nodes []*Node) {
+	for _, n := range nodes {
+		if n == nil || n.Provider == nil {
+			continue
+		}
+		if n.Provider.ReturnError {
+			ctor.ReturnError = true
+		}
+		if n.Provider.ReturnCleanup {
+			ctor.ReturnCleanup = true
+		}
+	}
+}
+
+// isContainerField reports whether p provides a field of a container.
+func isContainerField(p *Provider) bool {
+	return p.Container && p.Kind == ProviderField
+}
+
+// collectComposed registers the field providers of the containers composed by fields
+// with the compose directive as overrides, unless the container already overrides their bindings.
+func collectComposed(fields []ContainerField, providers []*Provider, overrides map[string]*Provider) error {
+	for _, f := range fields {
+		if !f.Inject.Compose {
+			continue
+		}
+		if !slices.ContainsFunc(providers, func(p *Provider) bool {
+			return p.Container && p.Kind == ProviderFunc && types.Identical(p.ResultType, f.Type)
+		}) {
+			return fmt.Errorf("field %s: compose requires a container, got %s", f.Name, typeString(f.Type))
+		}
+		for _, p := range providers {
+			if !isContainerField(p) || !types.Identical(p.Params[0], f.Type) {
+				continue
+			}
+			key := bindingKey(p.ResultType, p.Qualifier)
+			if existing, ok := overrides[key]; ok {
+				if isContainerField(existing) && existing != p {
+					return fmt.Errorf(
+						"%s is provided by both %s and %s",
+						bindingString(p.ResultType, p.Qualifier),
+						providerString(existing),
+						providerString(p),
+					)
+				}
+				continue
+			}
+			overrides[key] = p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package resolve
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mickamy/injector/internal/scan"
+)
+
+const composeSrc = `package app
+
+type DB struct{}
+type Cache struct{}
+type Server struct{}
+
+type Infra struct {
+	DB    *DB
+	Cache *Cache
+}
+
+type Other struct {
+	DB *DB
+}
+
+func NewDB() *DB { return &DB{} }
+func NewCache() *Cache { return &Cache{} }
+func NewServer(*DB, *Cache) *Server { return &Server{} }
+`
+
+func TestBuildGraphCompose(t *testing.T) {
+	pkg := checkSource(t, composeSrc)
+
+	// containerProviders returns the providers of the container name declared in composeSrc.
+	containerProviders := func(name string, fields ...fieldSpec) []*Provider {
+		c := scan.ContainerSpec{PkgPath: testPkgPath, Name: name, Type: lookupType(t, pkg, name)}
+		return ContainerProviders(c, containerFields(t, pkg, fields...), "New"+name, false)
+	}
+	infra := containerProviders("Infra", fieldSpec{name: "DB", typ: "*DB"}, fieldSpec{name: "Cache", typ: "*Cache"})
+	other := containerProviders("Other", fieldSpec{name: "DB", typ: "*DB"})
+
+	tests := []struct {
+		name    string
+		fields  []fieldSpec
+		want    [][]string
+		wantErr string
+	}{
+		{
+			name:   "fields of a composed container are reused",
+			fields: []fieldSpec{{name: "_", typ: "*Infra", tag: InjectTag{Compose: true}}, {name: "Server", typ: "*Server"}},
+			want:   [][]string{{"NewServer", "Infra.DB", "Infra.Cache"}},
+		},
+		{
+			name:   "named composed field",
+			fields: []fieldSpec{{name: "Infra", typ: "*Infra", tag: InjectTag{Compose: true}}, {name: "Server", typ: "*Server"}},
+			want:   [][]string{{"NewInfra"}, {"NewServer", "Infra.DB", "Infra.Cache"}},
+		},
+		{
+			name:   "container constructor without composition",
+			fields: []fieldSpec{{name: "Infra", typ: "*Infra"}},
+			want:   [][]string{{"NewInfra"}},
+		},
+		{
+			name:   "container field without the compose directive",
+			fields: []fieldSpec{{name: "Infra", typ: "*Infra"}, {name: "Server", typ: "*Server"}},
+			want:   [][]string{{"NewInfra"}, {"NewServer", "NewDB", "NewCache"}},
+		},
+		{
+			name:   "fields of containers that are not composed are ignored",
+			fields: []fieldSpec{{name: "Server", typ: "*Server"}},
+			want:   [][]string{{"NewServer", "NewDB", "NewCache"}},
+		},
+		{
+			name: "overrides take precedence over composed fields",
+			fields: []fieldSpec{
+				{name: "_", typ: "*Infra", tag: InjectTag{Compose: true}},
+				{name: "_", typ: "*DB", tag: InjectTag{Provider: "app.NewDB"}},
+				{name: "Server", typ: "*Server"},
+			},
+			want: [][]string{{"NewServer", "NewDB", "Infra.Cache"}},
+		},
+		{
+			name:    "composed containers providing the same binding",
+			fields:  []fieldSpec{{name: "_", typ: "*Infra", tag: InjectTag{Compose: true}}, {name: "_", typ: "*Other", tag: InjectTag{Compose: true}}, {name: "Server", typ: "*Server"}},
+			wantErr: "*example.com/app.DB is provided by both example.com/app.Infra.DB and example.com/app.Other.DB",
+		},
+		{
+			name:    "composing a type that is not a container",
+			fields:  []fieldSpec{{name: "_", typ: "*DB", tag: InjectTag{Compose: true}}, {name: "Server", typ: "*Server"}},
+			wantErr: "field _: compose requires a container, got *example.com/app.DB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := slices.Concat(funcProviders(t, pkg, nil, "NewDB", "NewCache", "NewServer"), infra, other)
+			g, err := buildGraph(containerFields(t, pkg, tt.fields...), providers, Options{})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := rootNames(g); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("roots = %v, want %v", got, tt.want)
+			}
+			for _, d := range g.Roots[len(g.Roots)-1].Deps {
+				if isContainerField(d.Provider) && (len(d.Deps) != 1 || d.Deps[0].Provider != infra[0]) {
+					t.Errorf("%s is not read from the value built by NewInfra", d.Provider.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertContainerFieldsCompose(t *testing.T) {
+	pkg := checkSource(t, composeSrc)
+	infra := lookupType(t, pkg, "*Infra")
+
+	tests := []struct {
+		name  string
+		field scan.ContainerField
+		want  []string
+	}{
+		{
+			name:  "blank field with the compose directive",
+			field: scan.ContainerField{Name: "_", Type: infra, TagRaw: `inject:"compose"`, InjectRaw: "compose", Inject: scan.InjectTag{Compose: true}},
+			want:  []string{"_"},
+		},
+		{
+			name:  "blank field without directives is ignored",
+			field: scan.ContainerField{Name: "_", Type: infra, TagRaw: `inject:""`},
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := ConvertContainerFields(scan.ContainerSpec{PkgPath: testPkgPath, Name: "App", Fields: []scan.ContainerField{tt.field}})
+			if err != nil {
+				t.Fatalf("ConvertContainerFields: %v", err)
+			}
+			var got []string
+			for _, f := range fields {
+				if !f.Inject.Compose {
+					t.Errorf("field %s is not composed", f.Name)
+				}
+				got = append(got, f.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
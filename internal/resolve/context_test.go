@@ -0,0 +1,91 @@
+package resolve
+
+import (
+	"slices"
+	"testing"
+)
+
+const contextSrc = `package app
+
+import "context"
+
+type DB struct{}
+type Server struct{}
+
+func NewContext() context.Context { return context.Background() }
+func NewDB(context.Context) (*DB, error) { return &DB{}, nil }
+func NewServer(context.Context, *DB) *Server { return &Server{} }
+func NewPlainServer() *Server { return &Server{} }
+`
+
+func TestBuildGraphContext(t *testing.T) {
+	pkg := checkSource(t, contextSrc)
+
+	tests := []struct {
+		name        string
+		providers   []string
+		context     bool
+		want        [][]string
+		wantContext string
+	}{
+		{
+			name:        "context is passed to every provider accepting it",
+			providers:   []string{"NewDB", "NewServer"},
+			context:     true,
+			want:        [][]string{{"NewServer", "ctx", "NewDB"}},
+			wantContext: "ctx",
+		},
+		{
+			name:        "context takes precedence over a provider of context.Context",
+			providers:   []string{"NewContext", "NewDB", "NewServer"},
+			context:     true,
+			want:        [][]string{{"NewServer", "ctx", "NewDB"}},
+			wantContext: "ctx",
+		},
+		{
+			name:      "without the option a provider of context.Context is used",
+			providers: []string{"NewContext", "NewDB", "NewServer"},
+			want:      [][]string{{"NewServer", "NewContext", "NewDB"}},
+		},
+		{
+			name:        "context is declared even when no provider accepts it",
+			providers:   []string{"NewPlainServer"},
+			context:     true,
+			want:        [][]string{{"NewPlainServer"}},
+			wantContext: "ctx",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := containerFields(t, pkg, fieldSpec{name: "Server", typ: "*Server"})
+			g, err := buildGraph(fields, funcProviders(t, pkg, nil, tt.providers...), Options{Context: tt.context})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := rootNames(g); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("roots = %v, want %v", got, tt.want)
+			}
+			if tt.wantContext == "" {
+				if g.Context != nil {
+					t.Errorf("context = %s, want none", g.Context.Provider.Name)
+				}
+				return
+			}
+			if g.Context == nil {
+				t.Fatalf("context = nil, want %s", tt.wantContext)
+			}
+			if got := g.Context.Provider.Name; got != tt.wantContext {
+				t.Errorf("context = %s, want %s", got, tt.wantContext)
+			}
+			if got := g.Context.Provider.ResultType.String(); got != contextTypeKey {
+				t.Errorf("context type = %s, want %s", got, contextTypeKey)
+			}
+			for _, dep := range g.Roots[0].Deps {
+				if dep.Provider.Name == "NewDB" && dep.Deps[0] != g.Context {
+					t.Errorf("NewDB does not share the context node")
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package resolve
 import (
 	"errors"
 	"fmt"
+	"go/types"
 	"strings"
 
 	"github.com/mickamy/injector/internal/scan"
@@ -24,6 +25,7 @@ func ConvertContainerFields(c scan.ContainerSpec) ([]ContainerField, error) {
 	}
 
 	var out []ContainerField
+	var lifecycle string
 	for _, f := range c.Fields {
 		// Blank field: override only, include only if marked.
 		if f.Name == "_" {
@@ -37,12 +39,26 @@ func ConvertContainerFields(c scan.ContainerSpec) ([]ContainerField, error) {
 			continue
 		}
 
+		if f.Inject.Lifecycle {
+			if err := validateLifecycleField(f, lifecycle); err != nil {
+				errs = append(errs, fmt.Sprintf("resolve: %s %v", f.Position, err))
+				continue
+			}
+			lifecycle = f.Name
+		}
+		if f.Inject.Lazy && f.Name == "_" {
+			errs = append(errs, fmt.Sprintf("resolve: %s lazy field must be named", f.Position))
+			continue
+		}
+		if len(f.Inject.With) > 0 && f.Name == "_" {
+			errs = append(errs, fmt.Sprintf("resolve: %s field with scoped overrides must be named", f.Position))
+			continue
+		}
+
 		out = append(out, ContainerField{
-			Name: f.Name,
-			Type: f.Type,
-			Inject: InjectTag{
-				Provider: f.Inject.Provider,
-			},
+			Name:   f.Name,
+			Type:   f.Type,
+			Inject: convertInjectTag(f.Inject),
 		})
 	}
 
@@ -63,14 +79,37 @@ func ConvertProviders(ps []scan.ProviderSpec) ([]*Provider, error) {
 			continue
 		}
 
+		kind := ProviderFunc
+		switch {
+		case p.Module != nil:
+			kind = ProviderMethod
+		case p.Struct:
+			kind = ProviderStruct
+		case p.FieldOf != nil:
+			kind = ProviderField
+		case p.Value:
+			kind = ProviderVar
+		}
+
 		out = append(out, &Provider{
-			PkgPath:     p.PkgPath,
-			Name:        p.Name,
-			NameWithPkg: strings.Join([]string{p.PkgPath, p.Name}, "."),
-			ResultType:  p.ResultType,
-			ReturnError: p.ReturnError,
-			Params:      p.Params,
-			Position:    p.Position,
+			Kind:          kind,
+			PkgPath:       p.PkgPath,
+			Name:          p.Name,
+			NameWithPkg:   strings.Join([]string{p.PkgPath, p.Name}, "."),
+			ResultType:    p.ResultType,
+			ReturnError:   p.ReturnError,
+			ReturnCleanup: p.ReturnCleanup,
+			Params:        p.Params,
+			Qualifier:     p.Qualifier,
+			ParamTags:     convertParamTags(p.ParamTags),
+			Groups:        convertGroups(p.Groups),
+			Generic:       p.Generic,
+			Fields:        p.Fields,
+			Var:           p.Var,
+			Transient:     p.Transient,
+			Profiles:      p.Profiles,
+			Test:          p.Test,
+			Position:      p.Position,
 		})
 	}
 
@@ -80,6 +119,84 @@ func ConvertProviders(ps []scan.ProviderSpec) ([]*Provider, error) {
 	return out, nil
 }
 
+// validateLifecycleField checks that f can hold lifecycle components.
+// prev is the name of a lifecycle field already seen in the container, if any.
+func validateLifecycleField(f scan.ContainerField, prev string) error {
+	if prev != "" {
+		return fmt.Errorf("lifecycle field %s conflicts with %s", f.Name, prev)
+	}
+	if f.Name == "_" {
+		return errors.New("lifecycle field must be named")
+	}
+	if !isAnySlice(f.Type) {
+		return fmt.Errorf("lifecycle field %s must be of type []any", f.Name)
+	}
+	return nil
+}
+
+func isAnySlice(t types.Type) bool {
+	s, ok := types.Unalias(t).(*types.Slice)
+	if !ok {
+		return false
+	}
+	iface, ok := types.Unalias(s.Elem()).(*types.Interface)
+	return ok && iface.Empty()
+}
+
+func convertInjectTag(t scan.InjectTag) InjectTag {
+	return InjectTag{
+		Provider:  t.Provider,
+		Name:      t.Name,
+		Bind:      t.Bind,
+		Arg:       t.Arg,
+		Lifecycle: t.Lifecycle,
+		Lazy:      t.Lazy,
+		Proxy:     t.Proxy,
+		Group:     t.Group,
+		Optional:  t.Optional,
+		Module:    t.Module,
+		Struct:    t.Struct,
+		StructAll: t.StructAll,
+		Fields:    t.Fields,
+		Transient: t.Transient,
+		Compose:   t.Compose,
+		With:      convertScopedOverrides(t.With),
+	}
+}
+
+func convertScopedOverrides(ws []scan.ScopedOverride) []ScopedOverride {
+	if len(ws) == 0 {
+		return nil
+	}
+	out := make([]ScopedOverride, len(ws))
+	for i, w := range ws {
+		out[i] = ScopedOverride{Type: w.Type, Provider: w.Provider}
+	}
+	return out
+}
+
+func convertParamTags(ts []scan.InjectTag) []InjectTag {
+	if len(ts) == 0 {
+		return nil
+	}
+	out := make([]InjectTag, len(ts))
+	for i, t := range ts {
+		out[i] = convertInjectTag(t)
+	}
+	return out
+}
+
+func convertGroups(gs []scan.GroupSpec) []Group {
+	if len(gs) == 0 {
+		return nil
+	}
+	out := make([]Group, len(gs))
+	for i, g := range gs {
+		out[i] = Group{Name: g.Name, Priority: g.Priority, Key: g.Key}
+	}
+	return out
+}
+
 func isMarkedField(f scan.ContainerField) bool {
 	// Marker-only: InjectRaw can be empty. We rely on the presence of the `inject` marker.
 	return hasInjectMarkerInRaw(f.TagRaw) || f.InjectRaw != ""
@@ -0,0 +1,176 @@
+package resolve
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// lookupGeneric instantiates the generic providers whose result type unifies with t.
+// Providers whose type arguments do not satisfy their constraints are not candidates;
+// if no provider is left, the first constraint error is returned.
+func (r *resolver) lookupGeneric(t types.Type, name string) ([]*Provider, error) {
+	var cands []*Provider
+	var firstErr error
+	for _, p := range r.generics {
+		if p.Qualifier != name {
+			continue
+		}
+		inst, err := r.instantiate(p, t)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if inst != nil {
+			cands = append(cands, inst)
+		}
+	}
+	if len(cands) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return cands, nil
+}
+
+// instantiate returns the instance of the generic provider p that yields t,
+// or nil if the result type of p does not unify with t.
+// Instances are memoized so that every request for t shares a node.
+func (r *resolver) instantiate(p *Provider, t types.Type) (*Provider, error) {
+	tparams := p.Generic.TypeParams()
+	bound := map[*types.TypeParam]types.Type{}
+	if !unify(p.ResultType, t, bound) {
+		return nil, nil
+	}
+
+	targs := make([]types.Type, tparams.Len())
+	keys := make([]string, tparams.Len())
+	for i := 0; i < tparams.Len(); i++ {
+		tp := tparams.At(i)
+		targ, ok := bound[tp]
+		if !ok {
+			return nil, fmt.Errorf(
+				"cannot infer type parameter %s of %s from %s",
+				tp.Obj().Name(),
+				providerString(p),
+				typeString(t),
+			)
+		}
+		targs[i] = targ
+		keys[i] = typeKey(targ)
+	}
+
+	key := p.NameWithPkg + "[" + strings.Join(keys, ",") + "]"
+	if inst, ok := r.instances[key]; ok {
+		return inst, nil
+	}
+
+	sig, err := types.Instantiate(r.typesCtx, p.Generic, targs, true)
+	if err != nil {
+		return nil, fmt.Errorf("cannot instantiate %s for %s: %w", providerString(p), typeString(t), err)
+	}
+	isig := sig.(*types.Signature)
+
+	inst := *p
+	inst.Generic = nil
+	inst.TypeArgs = targs
+	inst.ResultType = isig.Results().At(0).Type()
+	inst.Params = nil
+	for i := 0; i < isig.Params().Len(); i++ {
+		inst.Params = append(inst.Params, isig.Params().At(i).Type())
+	}
+	r.instances[key] = &inst
+	return &inst, nil
+}
+
+// unify reports whether the type x, which may refer to type parameters,
+// matches y, recording the type bound to each type parameter.
+func unify(x, y types.Type, bound map[*types.TypeParam]types.Type) bool {
+	y = types.Unalias(y)
+	switch x := types.Unalias(x).(type) {
+	case *types.TypeParam:
+		if b, ok := bound[x]; ok {
+			return types.Identical(b, y)
+		}
+		bound[x] = y
+		return true
+	case *types.Pointer:
+		y, ok := y.(*types.Pointer)
+		return ok && unify(x.Elem(), y.Elem(), bound)
+	case *types.Slice:
+		y, ok := y.(*types.Slice)
+		return ok && unify(x.Elem(), y.Elem(), bound)
+	case *types.Array:
+		y, ok := y.(*types.Array)
+		return ok && x.Len() == y.Len() && unify(x.Elem(), y.Elem(), bound)
+	case *types.Map:
+		y, ok := y.(*types.Map)
+		return ok && unify(x.Key(), y.Key(), bound) && unify(x.Elem(), y.Elem(), bound)
+	case *types.Chan:
+		y, ok := y.(*types.Chan)
+		return ok && x.Dir() == y.Dir() && unify(x.Elem(), y.Elem(), bound)
+	case *types.Named:
+		y, ok := y.(*types.Named)
+		if !ok || x.Origin().Obj() != y.Origin().Obj() {
+			return false
+		}
+		xa, ya := x.TypeArgs(), y.TypeArgs()
+		if xa.Len() != ya.Len() {
+			return false
+		}
+		for i := 0; i < xa.Len(); i++ {
+			if !unify(xa.At(i), ya.At(i), bound) {
+				return false
+			}
+		}
+		return true
+	case *types.Signature:
+		y, ok := y.(*types.Signature)
+		return ok && x.Variadic() == y.Variadic() &&
+			unifyTuple(x.Params(), y.Params(), bound) && unifyTuple(x.Results(), y.Results(), bound)
+	case *types.Interface:
+		y, ok := y.(*types.Interface)
+		if !ok || !x.IsMethodSet() || !y.IsMethodSet() {
+			// Constraint interfaces with type terms are not result types.
+			return ok && types.Identical(x, y)
+		}
+		if x.NumMethods() != y.NumMethods() {
+			return false
+		}
+		// Methods are sorted by their unique id.
+		for i := 0; i < x.NumMethods(); i++ {
+			xm, ym := x.Method(i), y.Method(i)
+			if xm.Id() != ym.Id() || !unify(xm.Type(), ym.Type(), bound) {
+				return false
+			}
+		}
+		return true
+	case *types.Struct:
+		y, ok := y.(*types.Struct)
+		if !ok || x.NumFields() != y.NumFields() {
+			return false
+		}
+		for i := 0; i < x.NumFields(); i++ {
+			xf, yf := x.Field(i), y.Field(i)
+			if xf.Id() != yf.Id() || xf.Embedded() != yf.Embedded() || x.Tag(i) != y.Tag(i) || !unify(xf.Type(), yf.Type(), bound) {
+				return false
+			}
+		}
+		return true
+	default:
+		return types.Identical(x, y)
+	}
+}
+
+// unifyTuple unifies the parameters or results of two signatures, ignoring their names.
+func unifyTuple(x, y *types.Tuple, bound map[*types.TypeParam]types.Type) bool {
+	if x.Len() != y.Len() {
+		return false
+	}
+	for i := 0; i < x.Len(); i++ {
+		if !unify(x.At(i).Type(), y.At(i).Type(), bound) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package resolve
+
+import (
+	"go/types"
+	"strings"
+	"testing"
+)
+
+const genericSrc = `package app
+
+type Config struct{}
+
+type Repo[T any] struct{}
+
+func NewRepo[T any]() *Repo[T] { return &Repo[T]{} }
+func NewGetter[T any]() func() T { return nil }
+func NewSource[T any]() interface{ Get() T } { return nil }
+func NewHandler[T any]() func(T) error { return nil }
+func NewConfig() *Config { return &Config{} }
+
+type Container struct {
+	Repo    *Repo[Config]
+	Getter  func() *Config
+	Source  interface{ Get() *Config }
+	Handler func(*Config) error
+	Loader  func() (*Config, error)
+}
+`
+
+func TestBuildGraphGeneric(t *testing.T) {
+	pkg := checkSource(t, genericSrc)
+	providers := funcProviders(t, pkg, nil, "NewRepo", "NewGetter", "NewSource", "NewHandler", "NewConfig")
+	st := lookupType(t, pkg, "Container").Underlying().(*types.Struct)
+
+	tests := []struct {
+		field string
+		want  string // the instantiated provider, or the error
+	}{
+		{field: "Repo", want: "NewRepo[app.Config]"},
+		{field: "Getter", want: "NewGetter[*app.Config]"},
+		{field: "Source", want: "NewSource[*app.Config]"},
+		{field: "Handler", want: "NewHandler[*app.Config]"},
+		{field: "Loader", want: "no provider for func() (*example.com/app.Config, error)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			var f *types.Var
+			for i := 0; i < st.NumFields(); i++ {
+				if st.Field(i).Name() == tt.field {
+					f = st.Field(i)
+				}
+			}
+			fields := []ContainerField{{Name: f.Name(), Type: f.Type()}}
+
+			g, err := buildGraph(fields, providers, Options{})
+			if err != nil {
+				if !strings.Contains(err.Error(), tt.want) {
+					t.Fatalf("BuildGraph: %v, want %s", err, tt.want)
+				}
+				return
+			}
+			root := g.Roots[0]
+			var targs []string
+			for _, a := range root.Provider.TypeArgs {
+				targs = append(targs, types.TypeString(a, (*types.Package).Name))
+			}
+			if got := root.Provider.Name + "[" + strings.Join(targs, ",") + "]"; got != tt.want {
+				t.Errorf("field %s is built by %s, want %s", tt.field, got, tt.want)
+			}
+		})
+	}
+}
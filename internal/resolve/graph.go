@@ -2,12 +2,15 @@ package resolve
 
 import (
 	"fmt"
+	"go/token"
 	"go/types"
+	"sort"
 	"strings"
 )
 
 // BuildGraph resolves dependencies starting from container fields.
-func BuildGraph(fields []ContainerField, providers []*Provider) (*Graph, error) {
+func BuildGraph(fields []ContainerField, providers []*Provider, opts Options) (*Graph, error) {
+	providers, inactive := profileProviders(visibleProviders(providers, opts.PkgPath), opts.Profile)
 	byType := indexProvidersByType(providers)
 	byName, err := indexProvidersByNameStrict(providers)
 	if err != nil {
@@ -19,132 +22,576 @@ func BuildGraph(fields []ContainerField, providers []*Provider) (*Graph, error)
 		return nil, fmt.Errorf("resolve: failed to collect overrides: %w", err)
 	}
 
-	// seen tracks providers that have already been fully resolved.
-	// It is used to avoid re-resolving the same provider multiple times
-	// and to allow shared nodes in the dependency graph (DAG).
-	seen := map[*Provider]struct{}{}
+	r := &resolver{
+		providers: providers,
+		inactive:  inactive,
+		byType:    byType,
+		byName:    byName,
+		overrides: overrides,
+		bindings:  map[string]types.Type{},
+		implicit:  opts.ImplicitBindings,
+		groups:    map[string]*Provider{},
+		zeros:     map[string]*Provider{},
+		generics:  genericProviders(providers),
+		instances: map[string]*Provider{},
+		typesCtx:  types.NewContext(),
+		nodes:     map[*Provider]*Node{},
+		stack:     map[*Provider]struct{}{},
+	}
 
-	// stack tracks providers that are currently being resolved
-	// in the active DFS path. It is used to detect circular dependencies.
-	stack := map[*Provider]struct{}{}
+	if err := r.collectBindings(fields); err != nil {
+		return nil, fmt.Errorf("resolve: failed to collect bindings: %w", err)
+	}
+
+	var ctx *Node
+	if opts.Context {
+		ctx, err = r.contextArg()
+		if err != nil {
+			return nil, fmt.Errorf("resolve: failed to add context: %w", err)
+		}
+	}
+
+	args, err := r.collectArgs(fields)
+	if err != nil {
+		return nil, fmt.Errorf("resolve: failed to collect args: %w", err)
+	}
+
+	if err := collectComposed(fields, providers, r.overrides); err != nil {
+		return nil, fmt.Errorf("resolve: failed to collect composed containers: %w", err)
+	}
 
 	var roots []*Node
+	var lazy []bool
 	for _, f := range fields {
-		if f.Name == "_" {
-			// override-only
+		if !f.Resolvable() {
 			continue
 		}
-		n, err := resolveField(f, byType, byName, overrides, seen, stack)
+		n, err := r.resolveField(f)
 		if err != nil {
 			return nil, fmt.Errorf("resolve: failed to resolve field: %w", err)
 		}
 		roots = append(roots, n)
+		lazy = append(lazy, f.Inject.Lazy)
 	}
 
-	return &Graph{Roots: roots}, nil
+	return &Graph{Roots: roots, Lazy: lazy, Args: args, Context: ctx, Missing: r.missing, Unused: r.unused}, nil
 }
 
-func resolveField(
-	f ContainerField,
-	byType map[string][]*Provider,
-	byName map[string]*Provider,
-	overrides map[string]*Provider,
-	seen map[*Provider]struct{},
-	stack map[*Provider]struct{},
-) (*Node, error) {
+// visibleProviders returns the providers that code in pkgPath can refer to:
+// unexported variables and container fields of other packages are left out,
+// and so are test providers of packages other than pkgPath and the package it tests,
+// whose unexported test providers are left out too.
+func visibleProviders(providers []*Provider, pkgPath string) []*Provider {
+	var out []*Provider
+	for _, p := range providers {
+		name := p.Name[strings.LastIndexByte(p.Name, '.')+1:]
+		if (p.Var || p.Container) && !token.IsExported(name) && p.PkgPath != pkgPath {
+			continue
+		}
+		if p.Test && p.PkgPath != pkgPath && (p.PkgPath+"_test" != pkgPath || !token.IsExported(name)) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// resolver holds the state of a single BuildGraph run.
+type resolver struct {
+	providers []*Provider
+	// inactive are the providers of other profiles than the active one.
+	inactive []*Provider
+	// byType indexes providers by binding key (type and qualifier).
+	byType map[string][]*Provider
+	byName map[string]*Provider
+	// overrides maps a binding key to the provider selected by a blank field.
+	overrides map[string]*Provider
+	// bindings maps an interface binding key to the concrete type selected by a blank `bind` field.
+	bindings map[string]types.Type
+	// implicit enables implicit interface bindings.
+	implicit bool
+	// groups maps a group binding key to the provider collecting its members.
+	groups map[string]*Provider
+	// zeros maps a binding key to the zero value provider of a missing optional dependency.
+	zeros map[string]*Provider
+	// missing lists the optional dependencies resolved to zero values.
+	missing []Missing
+	// generics are the generic providers, instantiated on demand.
+	generics []*Provider
+	// instances maps a generic provider and its type arguments to the instantiated provider.
+	instances map[string]*Provider
+	typesCtx  *types.Context
+
+	// nodes tracks providers that have already been fully resolved.
+	// It is used to avoid re-resolving the same provider multiple times
+	// and to share nodes in the dependency graph (DAG).
+	nodes map[*Provider]*Node
+
+	// stack tracks providers that are currently being resolved
+	// in the active DFS path. It is used to detect circular dependencies.
+	stack map[*Provider]struct{}
+
+	// scope holds the `with` overrides of the field being resolved, if any.
+	scope *scope
+	// unused lists the `with` overrides that were never used.
+	unused []UnusedOverride
+}
+
+func (r *resolver) resolveField(f ContainerField) (*Node, error) {
+	if len(f.Inject.With) == 0 {
+		return r.resolveFieldProvider(f)
+	}
+
+	s, err := r.newScope(f)
+	if err != nil {
+		return nil, err
+	}
+	r.scope = s
+	defer func() { r.scope = nil }()
+
+	n, err := r.resolveFieldProvider(f)
+	if err != nil {
+		return nil, err
+	}
+	r.unused = append(r.unused, s.unused()...)
+	return n, nil
+}
+
+// resolveFieldProvider selects the provider of f and resolves its node.
+func (r *resolver) resolveFieldProvider(f ContainerField) (*Node, error) {
 	var p *Provider
 
-	if f.Inject.Provider != "" {
+	t := f.Type
+	if f.Inject.Lazy {
 		var err error
-		ps, err := lookupProviderByDirective(byName, f.Inject.Provider)
+		t, _, err = LazyTarget(f)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+
+	if f.Inject.Group != "" {
+		var err error
+		p, err = r.groupProvider(t, f.Inject.Group, f.Inject.Optional)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	} else if f.Inject.Arg {
+		// Registered by collectArgs.
+		p = r.overrides[bindingKey(f.Type, f.Inject.Name)]
+	} else if f.Inject.Provider != "" {
+		var err error
+		ps, err := lookupProviderByDirective(r.byName, f.Inject.Provider)
 		if err != nil {
 			return nil, err
 		}
 		for _, provider := range ps {
-			if types.Identical(provider.ResultType, f.Type) {
+			if provider.Generic != nil {
+				inst, err := r.instantiate(provider, t)
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %w", f.Name, err)
+				}
+				if inst != nil {
+					p = inst
+				}
+				continue
+			}
+			if types.Identical(provider.ResultType, t) {
 				p = provider
 			}
 		}
 		if p == nil {
 			return nil, fmt.Errorf("failed to resolve provider %s on resolve field", f.Inject.Provider)
 		}
-	} else if o, ok := overrides[typeKey(f.Type)]; ok {
-		p = o
+	} else if f.Inject.Bind != "" {
+		bt, err := r.bindTarget(t, f.Inject.Bind)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		if f.Inject.Optional {
+			p, err = r.lookupOptional(bt, f.Inject.Name, "field "+f.Name)
+		} else {
+			p, err = r.lookup(bt, f.Inject.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
 	} else {
-		candidates := byType[typeKey(f.Type)]
-		if len(candidates) == 0 {
-			return nil, fmt.Errorf("no provider for %s", typeString(f.Type))
+		var err error
+		if f.Inject.Optional {
+			p, err = r.lookupOptional(t, f.Inject.Name, "field "+f.Name)
+		} else {
+			p, err = r.lookup(t, f.Inject.Name)
 		}
-		if len(candidates) > 1 {
-			return nil, fmt.Errorf("multiple providers for %s", typeString(f.Type))
+		if err != nil {
+			return nil, err
 		}
-		p = candidates[0]
 	}
 
 	// Ensure return type matches the requested field type when provider is explicitly selected.
-	if f.Inject.Provider != "" && !types.Identical(p.ResultType, f.Type) {
+	if f.Inject.Provider != "" && !types.Identical(p.ResultType, t) {
 		return nil, fmt.Errorf(
 			"provider %s returns %s, but field %s requires %s",
 			providerString(p),
 			typeString(p.ResultType),
 			f.Name,
-			typeString(f.Type),
+			typeString(t),
 		)
 	}
 
-	return resolveProvider(p, byType, byName, overrides, seen, stack)
+	if isTransient(p, f.Inject) {
+		return r.buildNode(p)
+	}
+	return r.resolveProvider(p)
 }
 
-func resolveProvider(
-	p *Provider,
-	byType map[string][]*Provider,
-	byName map[string]*Provider,
-	overrides map[string]*Provider,
-	seen map[*Provider]struct{},
-	stack map[*Provider]struct{},
-) (*Node, error) {
-	if _, ok := stack[p]; ok {
-		return nil, fmt.Errorf("circular dependency detected at %s", providerString(p))
+// resolveProvider returns the node of p shared by all its dependents.
+func (r *resolver) resolveProvider(p *Provider) (*Node, error) {
+	if r.scope != nil {
+		return r.resolveScoped(p)
 	}
-	if _, ok := seen[p]; ok {
-		// Note: returning a shallow node is OK for now.
-		// Topological ordering will be generated later from providers, not from node identity.
-		return &Node{Provider: p}, nil
+	if n, ok := r.nodes[p]; ok {
+		return n, nil
 	}
 
-	stack[p] = struct{}{}
-	defer delete(stack, p)
-	seen[p] = struct{}{}
+	n, err := r.buildNode(p)
+	if err != nil {
+		return nil, err
+	}
+	r.nodes[p] = n
+	return n, nil
+}
+
+// buildNode resolves a new node of p and its dependencies.
+// Dependencies are shared unless they are transient.
+func (r *resolver) buildNode(p *Provider) (*Node, error) {
+	if _, ok := r.stack[p]; ok {
+		return nil, fmt.Errorf("circular dependency detected at %s", providerString(p))
+	}
+
+	r.stack[p] = struct{}{}
+	defer delete(r.stack, p)
 
 	var deps []*Node
-	for _, t := range p.Params {
-		key := typeKey(t)
+	private := false
+	for i := range p.Params {
+		if r.scope != nil {
+			r.scope.hit = false
+		}
+		dp, err := r.dependency(p, i)
+		if err != nil {
+			return nil, fmt.Errorf("%w (required by %s)", err, providerString(p))
+		}
+		hit := r.scope != nil && r.scope.hit
 
-		var dp *Provider
-		if o, ok := overrides[key]; ok {
-			dp = o
+		var n *Node
+		if isTransient(dp, p.paramTag(i)) {
+			n, err = r.buildNode(dp)
 		} else {
-			cands := byType[key]
-			if len(cands) == 0 {
-				return nil, fmt.Errorf("no provider for %s (required by %s)", typeString(t), providerString(p))
-			}
-			if len(cands) > 1 {
-				return nil, fmt.Errorf("multiple providers for %s (required by %s)", typeString(t), providerString(p))
-			}
-			dp = cands[0]
+			n, err = r.resolveProvider(dp)
 		}
-
-		n, err := resolveProvider(dp, byType, byName, overrides, seen, stack)
 		if err != nil {
 			return nil, err
 		}
+		if hit || (r.scope != nil && r.scope.private[n]) {
+			private = true
+		}
 		deps = append(deps, n)
 	}
 
-	return &Node{
+	n := &Node{
 		Provider: p,
 		Deps:     deps,
-	}, nil
+	}
+	if private {
+		r.scope.private[n] = true
+	}
+	return n, nil
+}
+
+// isTransient reports whether the dependency on p tagged with tag gets its own node.
+// Only providers that build a value can be transient; arguments, groups and zero values are always shared.
+func isTransient(p *Provider, tag InjectTag) bool {
+	if !p.Transient && !tag.Transient {
+		return false
+	}
+	switch p.Kind {
+	case ProviderFunc, ProviderMethod, ProviderStruct:
+		return !p.Container
+	default:
+		return false
+	}
+}
+
+// dependency selects the provider of the i-th parameter of p.
+func (r *resolver) dependency(p *Provider, i int) (*Provider, error) {
+	if p.Kind == ProviderGroup {
+		return p.Members[i], nil
+	}
+	tag := p.paramTag(i)
+	if tag.Group != "" {
+		return r.groupProvider(p.Params[i], tag.Group, tag.Optional)
+	}
+	if tag.Optional {
+		return r.lookupOptional(p.Params[i], tag.Name, providerString(p))
+	}
+	return r.lookup(p.Params[i], tag.Name)
+}
+
+// lookup selects the provider for the binding (t, name).
+// Scoped overrides of the field being resolved take precedence over everything else.
+// Overrides take precedence over interface bindings,
+// which take precedence over providers discovered by type.
+func (r *resolver) lookup(t types.Type, name string) (*Provider, error) {
+	key := bindingKey(t, name)
+	if o, ok := r.scoped(key); ok {
+		return o, nil
+	}
+	if bt, ok := r.bindings[key]; ok && r.overrides[key] == nil {
+		t, key = bt, bindingKey(bt, name)
+	}
+	if o, ok := r.overrides[key]; ok {
+		return o, nil
+	}
+
+	cands := r.byType[key]
+	if len(cands) == 0 {
+		var err error
+		cands, err = r.lookupGeneric(t, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	cands = preferProfiled(preferTests(cands))
+	if len(cands) == 0 && r.implicit && types.IsInterface(t) {
+		return r.lookupImplicit(t, name)
+	}
+	if len(cands) == 0 {
+		return nil, fmt.Errorf("%w for %s%s", errNoProvider, bindingString(t, name), r.inactiveHint(t, name))
+	}
+	if len(cands) > 1 {
+		return nil, ambiguousError(t, name, cands)
+	}
+	return cands[0], nil
+}
+
+// ambiguousError reports the candidates for a binding with more than one provider
+// and how to select one. A `provider` directive on a named field only selects the provider of that field,
+// so the other dependents of the type need a selection of their own.
+func ambiguousError(t types.Type, name string, cands []*Provider) error {
+	names := make([]string, 0, len(cands))
+	for _, p := range cands {
+		names = append(names, providerString(p))
+	}
+	sort.Strings(names)
+	return fmt.Errorf(
+		"multiple providers for %s: %s; select one for the container with a blank field (_ %s `inject:\"provider:<name>\"`) or qualify them with //injector:name",
+		bindingString(t, name),
+		strings.Join(names, ", "),
+		types.TypeString(t, (*types.Package).Name),
+	)
+}
+
+// lookupImplicit selects the provider whose result implements the interface iface.
+// Candidates are grouped by result type, so the concrete type must be unique;
+// the provider for that type is then selected by lookup as usual.
+func (r *resolver) lookupImplicit(iface types.Type, name string) (*Provider, error) {
+	var cands []*Provider
+	for _, p := range r.providers {
+		if p.Qualifier != name || p.Generic != nil || isContainerField(p) || types.Identical(p.ResultType, iface) {
+			continue
+		}
+		if types.AssignableTo(p.ResultType, iface) {
+			cands = append(cands, p)
+		}
+	}
+
+	var impls []types.Type
+	seen := map[string]struct{}{}
+	for _, p := range preferProfiled(preferTests(cands)) {
+		key := typeKey(p.ResultType)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		impls = append(impls, p.ResultType)
+	}
+
+	if len(impls) == 0 {
+		return nil, fmt.Errorf("%w for %s", errNoProvider, bindingString(iface, name))
+	}
+	if len(impls) > 1 {
+		names := make([]string, 0, len(impls))
+		for _, t := range impls {
+			names = append(names, typeString(t))
+		}
+		return nil, fmt.Errorf(
+			"ambiguous implicit binding for %s: implemented by %s",
+			bindingString(iface, name),
+			strings.Join(names, ", "),
+		)
+	}
+	return r.lookup(impls[0], name)
+}
+
+// collectBindings registers interface bindings declared by blank fields with a `bind` directive.
+func (r *resolver) collectBindings(fields []ContainerField) error {
+	for _, f := range fields {
+		if f.Name != "_" || f.Inject.Bind == "" {
+			continue
+		}
+		t, err := r.bindTarget(f.Type, f.Inject.Bind)
+		if err != nil {
+			return err
+		}
+		r.bindings[bindingKey(f.Type, f.Inject.Name)] = t
+	}
+	return nil
+}
+
+// bindTarget finds the provider result type named by a `bind` directive
+// and checks that it implements the interface iface.
+// The directive may be qualified by package name (*postgres.Repo) or by package path.
+func (r *resolver) bindTarget(iface types.Type, expr string) (types.Type, error) {
+	if !types.IsInterface(iface) {
+		return nil, fmt.Errorf("bind:%s requires an interface type, got %s", expr, typeString(iface))
+	}
+
+	var found []types.Type
+	seen := map[string]struct{}{}
+	for _, p := range r.providers {
+		if typeString(p.ResultType) != expr && shortTypeString(p.ResultType) != expr {
+			continue
+		}
+		key := typeKey(p.ResultType)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		found = append(found, p.ResultType)
+	}
+
+	if len(found) == 0 {
+		return nil, fmt.Errorf("bind:%s: no provider returns %s", expr, expr)
+	}
+	if len(found) > 1 {
+		return nil, fmt.Errorf("bind:%s is ambiguous; qualify it with the package path", expr)
+	}
+	if !types.AssignableTo(found[0], iface) {
+		return nil, fmt.Errorf("bind:%s: %s does not implement %s", expr, typeString(found[0]), typeString(iface))
+	}
+	return found[0], nil
+}
+
+// collectArgs turns `inject:"arg"` fields into constructor parameters.
+// Args act as overrides for their binding, so they take precedence over discovered providers.
+func (r *resolver) collectArgs(fields []ContainerField) ([]*Node, error) {
+	var out []*Node
+	for _, f := range fields {
+		if !f.Inject.Arg {
+			continue
+		}
+
+		name, err := argName(f)
+		if err != nil {
+			return nil, err
+		}
+
+		key := bindingKey(f.Type, f.Inject.Name)
+		if existing, ok := r.overrides[key]; ok {
+			return nil, fmt.Errorf(
+				"arg %s conflicts with %s for %s",
+				name,
+				providerString(existing),
+				bindingString(f.Type, f.Inject.Name),
+			)
+		}
+
+		p := &Provider{
+			Kind:        ProviderArg,
+			Name:        name,
+			NameWithPkg: name,
+			ResultType:  f.Type,
+			Qualifier:   f.Inject.Name,
+		}
+		r.overrides[key] = p
+
+		n, err := r.resolveProvider(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// contextArg registers the context.Context constructor parameter.
+// It acts as an override, so it takes precedence over any discovered provider of context.Context.
+func (r *resolver) contextArg() (*Node, error) {
+	t := r.contextType()
+	key := bindingKey(t, "")
+	if existing, ok := r.overrides[key]; ok {
+		return nil, fmt.Errorf("context parameter conflicts with %s", providerString(existing))
+	}
+
+	p := &Provider{
+		Kind:        ProviderArg,
+		Name:        "ctx",
+		NameWithPkg: "ctx",
+		ResultType:  t,
+	}
+	r.overrides[key] = p
+	return r.resolveProvider(p)
+}
+
+// contextType returns the context.Context type used by the providers.
+// When no provider accepts a context, an equivalent type is synthesized
+// so the parameter can still be declared.
+func (r *resolver) contextType() types.Type {
+	for _, p := range r.providers {
+		for _, t := range p.Params {
+			if typeKey(t) == contextTypeKey {
+				return t
+			}
+		}
+	}
+	return syntheticContextType()
+}
+
+// syntheticContextType returns a type equivalent to context.Context for resolution purposes.
+func syntheticContextType() types.Type {
+	pkg := types.NewPackage("context", "context")
+	obj := types.NewTypeName(token.NoPos, pkg, "Context", nil)
+	return types.NewNamed(obj, types.NewInterfaceType(nil, nil), nil)
+}
+
+const contextTypeKey = "context.Context"
+
+// argName derives the constructor parameter name of an arg field.
+// Named fields use the field name; blank fields use the qualifier or the type name.
+func argName(f ContainerField) (string, error) {
+	if f.Name != "_" {
+		return lowerFirst(f.Name), nil
+	}
+	if f.Inject.Name != "" {
+		return lowerFirst(f.Inject.Name), nil
+	}
+
+	t := f.Type
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return lowerFirst(named.Obj().Name()), nil
+	}
+	return "", fmt.Errorf("arg of type %s on a blank field requires a name directive", typeString(f.Type))
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return ""
+	}
+	return strings.ToLower(s[:1]) + s[1:]
 }
 
 func collectOverrides(fields []ContainerField, byName map[string]*Provider) (map[string]*Provider, error) {
@@ -169,7 +616,7 @@ func collectOverrides(fields []ContainerField, byName map[string]*Provider) (map
 		if p == nil {
 			return nil, fmt.Errorf("failed to resolve provider %s on collect overrides", f.Inject.Provider)
 		}
-		out[typeKey(f.Type)] = p
+		out[bindingKey(f.Type, f.Inject.Name)] = p
 	}
 	return out, nil
 }
@@ -198,12 +645,30 @@ func lookupProviderByDirective(byName map[string]*Provider, directive string) ([
 func indexProvidersByType(ps []*Provider) map[string][]*Provider {
 	m := map[string][]*Provider{}
 	for _, p := range ps {
-		key := typeKey(p.ResultType)
+		if p.Generic != nil {
+			// Generic providers are matched by lookupGeneric.
+			continue
+		}
+		if isContainerField(p) {
+			// Container fields are only used by composing containers (see collectComposed).
+			continue
+		}
+		key := bindingKey(p.ResultType, p.Qualifier)
 		m[key] = append(m[key], p)
 	}
 	return m
 }
 
+func genericProviders(ps []*Provider) []*Provider {
+	var out []*Provider
+	for _, p := range ps {
+		if p.Generic != nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
 func indexProvidersByNameStrict(ps []*Provider) (map[string]*Provider, error) {
 	m := map[string]*Provider{}
 	var conflicts []string
@@ -228,22 +693,38 @@ func indexProvidersByNameStrict(ps []*Provider) (map[string]*Provider, error) {
 	return m, nil
 }
 
-func typeKey(t types.Type) string {
+// bindingKey identifies a binding by its type and optional qualifier.
+func bindingKey(t types.Type, name string) string {
+	if name == "" {
+		return typeKey(t)
+	}
+	return typeKey(t) + "#" + name
+}
+
+func bindingString(t types.Type, name string) string {
+	if name == "" {
+		return typeString(t)
+	}
+	return fmt.Sprintf("%s (name: %s)", typeString(t), name)
+}
+
+func typeString(t types.Type) string {
 	return types.TypeString(t, func(p *types.Package) string {
 		if p == nil {
 			return ""
 		}
+		// Use full path to reduce ambiguity in errors.
 		return p.Path()
 	})
 }
 
-func typeString(t types.Type) string {
+// shortTypeString formats t with package names instead of package paths (e.g. *postgres.Repo).
+func shortTypeString(t types.Type) string {
 	return types.TypeString(t, func(p *types.Package) string {
 		if p == nil {
 			return ""
 		}
-		// Use full path to reduce ambiguity in errors.
-		return p.Path()
+		return p.Name()
 	})
 }
 
@@ -251,6 +732,9 @@ func providerString(p *Provider) string {
 	if p == nil {
 		return "<nil>"
 	}
+	if p.Kind == ProviderGroup {
+		return p.NameWithPkg
+	}
 	if p.PkgPath == "" {
 		return p.Name
 	}
@@ -0,0 +1,235 @@
+package resolve
+
+import (
+	"slices"
+	"testing"
+)
+
+const bindingSrc = `package app
+
+type DB struct{}
+type Repo struct{}
+type Cache struct{}
+
+func NewPrimary() *DB { return &DB{} }
+func NewReplica() *DB { return &DB{} }
+func NewRepo(*DB) *Repo { return &Repo{} }
+func NewCache(*DB) *Cache { return &Cache{} }
+`
+
+func TestBuildGraphNamedBindings(t *testing.T) {
+	pkg := checkSource(t, bindingSrc)
+
+	qualified := func(ps map[string]*Provider) {
+		ps["NewPrimary"].Qualifier = "primary"
+		ps["NewReplica"].Qualifier = "replica"
+		ps["NewRepo"].ParamTags = []InjectTag{{Name: "replica"}}
+	}
+
+	tests := []struct {
+		name      string
+		configure func(map[string]*Provider)
+		fields    []fieldSpec
+		want      [][]string // per root: the root provider, then its dependencies
+		wantErr   string
+	}{
+		{
+			name:      "field selects a qualified binding",
+			configure: qualified,
+			fields:    []fieldSpec{{name: "Primary", typ: "*DB", tag: InjectTag{Name: "primary"}}},
+			want:      [][]string{{"NewPrimary"}},
+		},
+		{
+			name:      "parameter selects a qualified binding",
+			configure: qualified,
+			fields:    []fieldSpec{{name: "Repo", typ: "*Repo"}},
+			want:      [][]string{{"NewRepo", "NewReplica"}},
+		},
+		{
+			name:      "qualified bindings do not satisfy the default binding",
+			configure: qualified,
+			fields:    []fieldSpec{{name: "Cache", typ: "*Cache"}},
+			wantErr:   "no provider for *example.com/app.DB (required by",
+		},
+		{
+			name:      "missing qualified binding",
+			configure: qualified,
+			fields:    []fieldSpec{{name: "DB", typ: "*DB", tag: InjectTag{Name: "archive"}}},
+			wantErr:   "no provider for *example.com/app.DB (name: archive)",
+		},
+		{
+			name:      "blank field overrides a qualified binding",
+			configure: qualified,
+			fields: []fieldSpec{
+				{name: "_", typ: "*DB", tag: InjectTag{Name: "replica", Provider: "app.NewPrimary"}},
+				{name: "Repo", typ: "*Repo"},
+			},
+			want: [][]string{{"NewRepo", "NewPrimary"}},
+		},
+		{
+			name: "unqualified providers of the same type are ambiguous",
+			fields: []fieldSpec{
+				{name: "DB", typ: "*DB"},
+			},
+			wantErr: "multiple providers for *example.com/app.DB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := funcProviders(t, pkg, tt.configure, "NewPrimary", "NewReplica", "NewRepo", "NewCache")
+			g, err := buildGraph(containerFields(t, pkg, tt.fields...), providers, Options{})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+
+			if got := rootNames(g); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("roots = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+const bindSrc = `package app
+
+type Store interface{ Get() string }
+
+type Mem struct{}
+
+func (*Mem) Get() string { return "" }
+
+type Disk struct{}
+
+func (*Disk) Get() string { return "" }
+
+type Service struct{}
+
+func NewMem() *Mem { return &Mem{} }
+func NewDisk() *Disk { return &Disk{} }
+func NewService(Store) *Service { return &Service{} }
+`
+
+func TestBuildGraphInterfaceBindings(t *testing.T) {
+	pkg := checkSource(t, bindSrc)
+
+	tests := []struct {
+		name      string
+		providers []string
+		fields    []fieldSpec
+		implicit  bool
+		want      [][]string
+		wantErr   string
+	}{
+		{
+			name:      "blank field binds an interface",
+			providers: []string{"NewMem", "NewDisk", "NewService"},
+			fields: []fieldSpec{
+				{name: "_", typ: "Store", tag: InjectTag{Bind: "*app.Mem"}},
+				{name: "Service", typ: "*Service"},
+			},
+			want: [][]string{{"NewService", "NewMem"}},
+		},
+		{
+			name:      "field binds its interface",
+			providers: []string{"NewMem", "NewDisk"},
+			fields:    []fieldSpec{{name: "Store", typ: "Store", tag: InjectTag{Bind: "*example.com/app.Disk"}}},
+			want:      [][]string{{"NewDisk"}},
+		},
+		{
+			name:      "implicit binding to the only implementation",
+			providers: []string{"NewMem", "NewService"},
+			fields:    []fieldSpec{{name: "Service", typ: "*Service"}},
+			implicit:  true,
+			want:      [][]string{{"NewService", "NewMem"}},
+		},
+		{
+			name:      "ambiguous implicit binding",
+			providers: []string{"NewMem", "NewDisk", "NewService"},
+			fields:    []fieldSpec{{name: "Service", typ: "*Service"}},
+			implicit:  true,
+			wantErr:   "ambiguous implicit binding for example.com/app.Store: implemented by *example.com/app.Mem, *example.com/app.Disk",
+		},
+		{
+			name:      "interfaces are not bound implicitly by default",
+			providers: []string{"NewMem", "NewService"},
+			fields:    []fieldSpec{{name: "Service", typ: "*Service"}},
+			wantErr:   "no provider for example.com/app.Store",
+		},
+		{
+			name:      "bound type must implement the interface",
+			providers: []string{"NewMem", "NewService"},
+			fields:    []fieldSpec{{name: "_", typ: "Store", tag: InjectTag{Bind: "*app.Service"}}},
+			wantErr:   "bind:*app.Service: *example.com/app.Service does not implement example.com/app.Store",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := funcProviders(t, pkg, nil, tt.providers...)
+			g, err := buildGraph(containerFields(t, pkg, tt.fields...), providers, Options{ImplicitBindings: tt.implicit})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := rootNames(g); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("roots = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildGraphAmbiguous(t *testing.T) {
+	pkg := checkSource(t, bindingSrc)
+
+	tests := []struct {
+		name    string
+		fields  []fieldSpec
+		want    [][]string
+		wantErr string
+	}{
+		{
+			name:   "candidates and selections are listed",
+			fields: []fieldSpec{{name: "Repo", typ: "*Repo"}},
+			wantErr: "multiple providers for *example.com/app.DB: example.com/app.NewPrimary, example.com/app.NewReplica; " +
+				"select one for the container with a blank field (_ *app.DB `inject:\"provider:<name>\"`) or qualify them with //injector:name " +
+				"(required by example.com/app.NewRepo)",
+		},
+		{
+			name: "provider directive selects the provider of its field only",
+			fields: []fieldSpec{
+				{name: "DB", typ: "*DB", tag: InjectTag{Provider: "app.NewReplica"}},
+				{name: "Repo", typ: "*Repo"},
+			},
+			wantErr: "multiple providers for *example.com/app.DB: example.com/app.NewPrimary, example.com/app.NewReplica;",
+		},
+		{
+			name: "fields of the same type select different providers",
+			fields: []fieldSpec{
+				{name: "Primary", typ: "*DB", tag: InjectTag{Provider: "app.NewPrimary"}},
+				{name: "Replica", typ: "*DB", tag: InjectTag{Provider: "app.NewReplica"}},
+			},
+			want: [][]string{{"NewPrimary"}, {"NewReplica"}},
+		},
+		{
+			name: "blank field selects the provider for the container",
+			fields: []fieldSpec{
+				{name: "_", typ: "*DB", tag: InjectTag{Provider: "app.NewReplica"}},
+				{name: "Repo", typ: "*Repo"},
+				{name: "Cache", typ: "*Cache"},
+			},
+			want: [][]string{{"NewRepo", "NewReplica"}, {"NewCache", "NewReplica"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := funcProviders(t, pkg, nil, "NewPrimary", "NewReplica", "NewRepo", "NewCache")
+			g, err := buildGraph(containerFields(t, pkg, tt.fields...), providers, Options{})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := rootNames(g); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("roots = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package resolve
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// groupProvider returns the provider collecting the members of group into a value of type t.
+// Members are the providers annotated with `//injector:group <group>`.
+//
+// When t is a slice, members are ordered by descending priority, then package path and name.
+// When t is a map with string keys, each member is stored under the key of its annotation;
+// members without a key or sharing a key are errors.
+// A group without members is an error unless optional is set.
+func (r *resolver) groupProvider(t types.Type, group string, optional bool) (*Provider, error) {
+	var elem types.Type
+	var isMap bool
+	switch u := types.Unalias(t).Underlying().(type) {
+	case *types.Slice:
+		elem = u.Elem()
+	case *types.Map:
+		k, ok := u.Key().Underlying().(*types.Basic)
+		if !ok || k.Info()&types.IsString == 0 {
+			return nil, fmt.Errorf("group:%s requires a map with string keys, got %s", group, typeString(t))
+		}
+		elem = u.Elem()
+		isMap = true
+	default:
+		return nil, fmt.Errorf("group:%s requires a slice or map type, got %s", group, typeString(t))
+	}
+
+	key := bindingKey(t, group)
+	if p, ok := r.groups[key]; ok {
+		if len(p.Members) == 0 && !optional {
+			return nil, fmt.Errorf("group %s has no providers", group)
+		}
+		return p, nil
+	}
+
+	type member struct {
+		p        *Provider
+		priority int
+		key      string
+	}
+	var members []member
+	for _, p := range r.providers {
+		for _, g := range p.Groups {
+			if g.Name != group {
+				continue
+			}
+			if p.Generic != nil {
+				return nil, fmt.Errorf("generic provider %s cannot be a member of group %s", providerString(p), group)
+			}
+			if !types.AssignableTo(p.ResultType, elem) {
+				return nil, fmt.Errorf(
+					"provider %s in group %s returns %s, which is not assignable to %s",
+					providerString(p),
+					group,
+					typeString(p.ResultType),
+					typeString(elem),
+				)
+			}
+			if isMap && g.Key == "" {
+				return nil, fmt.Errorf("provider %s in group %s has no key; annotate it with key=<key>", providerString(p), group)
+			}
+			members = append(members, member{p: p, priority: g.Priority, key: g.Key})
+		}
+	}
+	if len(members) == 0 && !optional {
+		return nil, fmt.Errorf("group %s has no providers", group)
+	}
+
+	if isMap {
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].key < members[j].key
+		})
+		for i := 1; i < len(members); i++ {
+			if members[i].key == members[i-1].key {
+				return nil, fmt.Errorf(
+					"providers %s and %s use the same key %q in group %s",
+					providerString(members[i-1].p),
+					providerString(members[i].p),
+					members[i].key,
+					group,
+				)
+			}
+		}
+	} else {
+		sort.SliceStable(members, func(i, j int) bool {
+			a, b := members[i], members[j]
+			if a.priority != b.priority {
+				return a.priority > b.priority
+			}
+			if a.p.PkgPath != b.p.PkgPath {
+				return a.p.PkgPath < b.p.PkgPath
+			}
+			return a.p.Name < b.p.Name
+		})
+	}
+
+	p := &Provider{
+		Kind:        ProviderGroup,
+		Name:        group,
+		NameWithPkg: "group:" + group,
+		ResultType:  t,
+	}
+	for _, m := range members {
+		p.Params = append(p.Params, m.p.ResultType)
+		p.Members = append(p.Members, m.p)
+		if isMap {
+			p.Keys = append(p.Keys, m.key)
+		}
+	}
+	r.groups[key] = p
+	return p, nil
+}
@@ -0,0 +1,130 @@
+package resolve
+
+import (
+	"slices"
+	"testing"
+)
+
+const groupSrc = `package app
+
+type Route interface{ Pattern() string }
+
+type Health struct{}
+type Users struct{}
+type Orders struct{}
+type Config struct{}
+type Router struct{}
+type RouteMap map[string]Route
+type RouteIndex map[int]Route
+
+func (*Health) Pattern() string { return "/health" }
+func (*Users) Pattern() string  { return "/users" }
+func (*Orders) Pattern() string { return "/orders" }
+
+func NewHealth() *Health { return &Health{} }
+func NewUsers() *Users { return &Users{} }
+func NewOrders() *Orders { return &Orders{} }
+func NewConfig() *Config { return &Config{} }
+func NewRouter(routes []Route) *Router { return &Router{} }
+`
+
+func TestBuildGraphGroups(t *testing.T) {
+	pkg := checkSource(t, groupSrc)
+
+	routes := func(members map[string]Group) func(map[string]*Provider) {
+		return func(ps map[string]*Provider) {
+			for name, g := range members {
+				g.Name = "routes"
+				ps[name].Groups = []Group{g}
+			}
+			ps["NewRouter"].ParamTags = []InjectTag{{Group: "routes"}}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		field     fieldSpec
+		configure func(map[string]*Provider)
+		want      [][]string
+		wantKeys  []string
+		wantErr   string
+	}{
+		{
+			name:      "members ordered by priority, then name",
+			field:     fieldSpec{name: "Routes", typ: "[]Route", tag: InjectTag{Group: "routes"}},
+			configure: routes(map[string]Group{"NewHealth": {}, "NewUsers": {Priority: 10}, "NewOrders": {}}),
+			want:      [][]string{{"routes", "NewUsers", "NewHealth", "NewOrders"}},
+		},
+		{
+			name:      "keyed members ordered by key",
+			field:     fieldSpec{name: "Routes", typ: "RouteMap", tag: InjectTag{Group: "routes"}},
+			configure: routes(map[string]Group{"NewHealth": {Key: "health"}, "NewUsers": {Key: "users", Priority: 10}, "NewOrders": {Key: "orders"}}),
+			want:      [][]string{{"routes", "NewHealth", "NewOrders", "NewUsers"}},
+			wantKeys:  []string{"health", "orders", "users"},
+		},
+		{
+			name:      "keyed member without a key",
+			field:     fieldSpec{name: "Routes", typ: "RouteMap", tag: InjectTag{Group: "routes"}},
+			configure: routes(map[string]Group{"NewHealth": {Key: "health"}, "NewUsers": {}}),
+			wantErr:   "provider example.com/app.NewUsers in group routes has no key; annotate it with key=<key>",
+		},
+		{
+			name:      "keyed members sharing a key",
+			field:     fieldSpec{name: "Routes", typ: "RouteMap", tag: InjectTag{Group: "routes"}},
+			configure: routes(map[string]Group{"NewHealth": {Key: "health"}, "NewUsers": {Key: "health"}}),
+			wantErr:   `providers example.com/app.NewHealth and example.com/app.NewUsers use the same key "health" in group routes`,
+		},
+		{
+			name:      "map group without string keys",
+			field:     fieldSpec{name: "Routes", typ: "RouteIndex", tag: InjectTag{Group: "routes"}},
+			configure: routes(map[string]Group{"NewHealth": {Key: "health"}}),
+			wantErr:   "group:routes requires a map with string keys, got example.com/app.RouteIndex",
+		},
+		{
+			name:      "group collected into a provider parameter",
+			field:     fieldSpec{name: "Router", typ: "*Router"},
+			configure: routes(map[string]Group{"NewHealth": {}}),
+			want:      [][]string{{"NewRouter", "routes"}},
+		},
+		{
+			name:      "optional group without members",
+			field:     fieldSpec{name: "Routes", typ: "[]Route", tag: InjectTag{Group: "routes", Optional: true}},
+			configure: routes(nil),
+			want:      [][]string{{"routes"}},
+		},
+		{
+			name:      "group without members",
+			field:     fieldSpec{name: "Routes", typ: "[]Route", tag: InjectTag{Group: "routes"}},
+			configure: routes(nil),
+			wantErr:   "group routes has no providers",
+		},
+		{
+			name:      "member not assignable to the element type",
+			field:     fieldSpec{name: "Routes", typ: "[]Route", tag: InjectTag{Group: "routes"}},
+			configure: routes(map[string]Group{"NewHealth": {}, "NewConfig": {}}),
+			wantErr:   "provider example.com/app.NewConfig in group routes returns *example.com/app.Config, which is not assignable to example.com/app.Route",
+		},
+		{
+			name:      "group field of a non-slice type",
+			field:     fieldSpec{name: "Route", typ: "Route", tag: InjectTag{Group: "routes"}},
+			configure: routes(map[string]Group{"NewHealth": {}}),
+			wantErr:   "group:routes requires a slice or map type, got example.com/app.Route",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := funcProviders(t, pkg, tt.configure, "NewHealth", "NewUsers", "NewOrders", "NewConfig", "NewRouter")
+			g, err := buildGraph(containerFields(t, pkg, tt.field), providers, Options{})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := rootNames(g); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("roots = %v, want %v", got, tt.want)
+			}
+			if got := g.Roots[0].Provider.Keys; !slices.Equal(got, tt.wantKeys) {
+				t.Errorf("keys = %v, want %v", got, tt.wantKeys)
+			}
+		})
+	}
+}
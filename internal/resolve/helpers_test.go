@@ -0,0 +1,156 @@
+package resolve
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+const testPkgPath = "example.com/app"
+
+// checkSource type-checks src as the package example.com/app.
+func checkSource(t *testing.T, src string) *types.Package {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "app.go", src, 0)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check(testPkgPath, fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	return pkg
+}
+
+// lookupType returns the type named by expr in pkg: a type name, optionally prefixed with "*" or "[]".
+func lookupType(t *testing.T, pkg *types.Package, expr string) types.Type {
+	t.Helper()
+
+	if elem, ok := strings.CutPrefix(expr, "*"); ok {
+		return types.NewPointer(lookupType(t, pkg, elem))
+	}
+	if elem, ok := strings.CutPrefix(expr, "[]"); ok {
+		return types.NewSlice(lookupType(t, pkg, elem))
+	}
+	obj, ok := pkg.Scope().Lookup(expr).(*types.TypeName)
+	if !ok {
+		t.Fatalf("type %s not found", expr)
+	}
+	return obj.Type()
+}
+
+// funcProvider returns a provider of the function name in pkg, generic or not.
+func funcProvider(t *testing.T, pkg *types.Package, name string) *Provider {
+	t.Helper()
+
+	fn, ok := pkg.Scope().Lookup(name).(*types.Func)
+	if !ok {
+		t.Fatalf("func %s not found", name)
+	}
+	sig := fn.Type().(*types.Signature)
+	p := &Provider{
+		PkgPath:     testPkgPath,
+		Name:        name,
+		NameWithPkg: testPkgPath + "." + name,
+		ResultType:  sig.Results().At(0).Type(),
+	}
+	for i := 1; i < sig.Results().Len(); i++ {
+		switch rt := sig.Results().At(i).Type(); rt.String() {
+		case "error":
+			p.ReturnError = true
+		case "func()":
+			p.ReturnCleanup = true
+		}
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		p.Params = append(p.Params, sig.Params().At(i).Type())
+	}
+	if sig.TypeParams().Len() > 0 {
+		p.Generic = sig
+	}
+	return p
+}
+
+// funcProviders returns the providers of the functions names in pkg, keyed by name.
+// configure, if not nil, adjusts them (e.g. sets qualifiers) before they are returned in names order.
+func funcProviders(t *testing.T, pkg *types.Package, configure func(map[string]*Provider), names ...string) []*Provider {
+	t.Helper()
+
+	out := make([]*Provider, 0, len(names))
+	byName := map[string]*Provider{}
+	for _, name := range names {
+		p := funcProvider(t, pkg, name)
+		out = append(out, p)
+		byName[name] = p
+	}
+	if configure != nil {
+		configure(byName)
+	}
+	return out
+}
+
+// fieldSpec describes a container field: its name, its type (see lookupType) and its tag.
+type fieldSpec struct {
+	name string
+	typ  string
+	tag  InjectTag
+}
+
+// containerFields returns the container fields described by specs.
+func containerFields(t *testing.T, pkg *types.Package, specs ...fieldSpec) []ContainerField {
+	t.Helper()
+
+	out := make([]ContainerField, 0, len(specs))
+	for _, s := range specs {
+		out = append(out, ContainerField{Name: s.name, Type: lookupType(t, pkg, s.typ), Inject: s.tag})
+	}
+	return out
+}
+
+// buildGraph resolves fields as the fields of a container declared in opts.PkgPath, example.com/app by default.
+func buildGraph(fields []ContainerField, providers []*Provider, opts Options) (*Graph, error) {
+	if opts.PkgPath == "" {
+		opts.PkgPath = testPkgPath
+	}
+	return BuildGraph(fields, providers, opts)
+}
+
+// rootNames returns, for each root of g, the name of its provider followed by those of its dependencies.
+func rootNames(g *Graph) [][]string {
+	var out [][]string
+	for _, r := range g.Roots {
+		out = append(out, append([]string{r.Provider.Name}, nodeNames(r.Deps)...))
+	}
+	return out
+}
+
+// nodeNames returns the provider names of nodes.
+func nodeNames(nodes []*Node) []string {
+	out := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		out = append(out, n.Provider.Name)
+	}
+	return out
+}
+
+// checkError reports whether err matches want: nil for "", or an error containing want.
+func checkError(t *testing.T, err error, want string) bool {
+	t.Helper()
+
+	switch {
+	case want == "" && err != nil:
+		t.Fatalf("unexpected error: %v", err)
+	case want != "" && err == nil:
+		t.Fatalf("error = nil, want %q", want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Fatalf("error = %v, want %q", err, want)
+	}
+	return err == nil
+}
@@ -0,0 +1,243 @@
+package resolve
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+)
+
+// LazyTarget returns the type built for the lazy field f and
+// whether its getter reports construction errors.
+//
+// Supported field shapes:
+// - func() T
+// - func() (T, error)
+// - an interface type, when the field is a lazy proxy
+func LazyTarget(f ContainerField) (types.Type, bool, error) {
+	if f.Inject.Proxy {
+		if !types.IsInterface(f.Type) {
+			return nil, false, fmt.Errorf("lazy:proxy requires an interface type, got %s", typeString(f.Type))
+		}
+		return f.Type, false, nil
+	}
+
+	sig, ok := types.Unalias(f.Type).(*types.Signature)
+	if !ok || sig.Params().Len() != 0 || sig.Variadic() {
+		return nil, false, fmt.Errorf("lazy field must be of type func() T or func() (T, error), got %s", typeString(f.Type))
+	}
+	res := sig.Results()
+	switch res.Len() {
+	case 1:
+		return res.At(0).Type(), false, nil
+	case 2:
+		if isErrorType(res.At(1).Type()) {
+			return res.At(0).Type(), true, nil
+		}
+	}
+	return nil, false, fmt.Errorf("lazy field must be of type func() T or func() (T, error), got %s", typeString(f.Type))
+}
+
+func isErrorType(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// LazyOrder lists the nodes built on the first use of a lazy field rather than by the constructor.
+type LazyOrder struct {
+	// Fields is aligned with g.Roots and lists, for each lazy field,
+	// the nodes its getter builds in topological order (dependencies first); eager roots get nil.
+	Fields [][]*Node
+	// Shared are the nodes that several lazy fields depend on, dependencies first.
+	// Each one is built once by a getter of its own, called by the getters that depend on it.
+	Shared []SharedLazy
+}
+
+// SharedLazy is a node built on first use that several lazy fields depend on.
+type SharedLazy struct {
+	Node *Node
+	// Nodes are the nodes its getter builds in topological order, ending with Node.
+	Nodes []*Node
+}
+
+// Nodes returns every node of o: the nodes of the shared getters, then the nodes of the fields.
+func (o LazyOrder) Nodes() []*Node {
+	var out []*Node
+	for _, s := range o.Shared {
+		out = append(out, s.Nodes...)
+	}
+	for _, ns := range o.Fields {
+		out = append(out, ns...)
+	}
+	return out
+}
+
+// OrderLazyNodes returns the nodes built on the first use of the lazy fields of g.
+//
+// The nodes of a lazy field are those not built by the constructor (see OrderNodes),
+// so dependencies shared with eager fields are never built twice.
+// A node that several lazy fields depend on is built by a shared getter instead,
+// so it stays a single instance without being built by the constructor.
+func OrderLazyNodes(g *Graph) (LazyOrder, error) {
+	if g == nil {
+		return LazyOrder{}, errors.New("resolve: graph is nil")
+	}
+
+	eager := eagerNodes(g)
+	shared := sharedLazyNodes(g, eager)
+	own := func(n *Node) bool {
+		_, ok := eager[n]
+		return !ok && !shared[n]
+	}
+
+	out := LazyOrder{Fields: make([][]*Node, len(g.Roots))}
+	for i, r := range g.Roots {
+		if !g.isLazy(i) {
+			continue
+		}
+		nodes, err := orderFrom([]*Node{r}, own)
+		if err != nil {
+			return LazyOrder{}, err
+		}
+		out.Fields[i] = nodes
+	}
+
+	// Order the shared nodes so that a getter is declared before the getters calling it.
+	var starts []*Node
+	for i, r := range g.Roots {
+		if g.isLazy(i) {
+			starts = append(starts, r)
+		}
+	}
+	order, err := orderFrom(starts, func(n *Node) bool {
+		_, ok := eager[n]
+		return !ok
+	})
+	if err != nil {
+		return LazyOrder{}, err
+	}
+	for _, s := range order {
+		if !shared[s] {
+			continue
+		}
+		nodes, err := orderFrom([]*Node{s}, func(n *Node) bool {
+			return n == s || own(n)
+		})
+		if err != nil {
+			return LazyOrder{}, err
+		}
+		out.Shared = append(out.Shared, SharedLazy{Node: s, Nodes: nodes})
+	}
+	return out, nil
+}
+
+func (g *Graph) isLazy(i int) bool {
+	return i < len(g.Lazy) && g.Lazy[i]
+}
+
+// eagerNodes returns the set of nodes built by the constructor:
+// every node reachable from an eager root, the args and the context.
+func eagerNodes(g *Graph) map[*Node]struct{} {
+	eager := map[*Node]struct{}{}
+
+	var mark func(n *Node)
+	mark = func(n *Node) {
+		if n == nil {
+			return
+		}
+		if _, ok := eager[n]; ok {
+			return
+		}
+		eager[n] = struct{}{}
+		for _, d := range n.Deps {
+			mark(d)
+		}
+	}
+
+	for i, r := range g.Roots {
+		if !g.isLazy(i) {
+			mark(r)
+		}
+	}
+	for _, a := range g.Args {
+		mark(a)
+	}
+	mark(g.Context)
+	return eager
+}
+
+// sharedLazyNodes returns the nodes not built by the constructor that more than one getter depends on.
+//
+// The getters are those of the lazy fields and of the shared nodes found so far.
+// A getter owns the nodes it reaches without going through the constructor or another shared node.
+// Only the topmost nodes owned by several getters become shared, since those below them
+// may be owned by the new getter alone, so repeat until nothing is shared anew.
+func sharedLazyNodes(g *Graph, eager map[*Node]struct{}) map[*Node]bool {
+	shared := map[*Node]bool{}
+	for {
+		owners := map[*Node]int{}
+		own := func(start *Node) {
+			seen := map[*Node]struct{}{}
+			var walk func(n *Node)
+			walk = func(n *Node) {
+				if n == nil {
+					return
+				}
+				if _, ok := eager[n]; ok {
+					return
+				}
+				if _, ok := seen[n]; ok {
+					return
+				}
+				if n != start && shared[n] {
+					return
+				}
+				seen[n] = struct{}{}
+				owners[n]++
+				for _, d := range n.Deps {
+					walk(d)
+				}
+			}
+			walk(start)
+		}
+		for i, r := range g.Roots {
+			if g.isLazy(i) && !shared[r] {
+				own(r)
+			}
+		}
+		for n := range shared {
+			own(n)
+		}
+
+		// A node below another node owned by several getters is left to the next round.
+		below := map[*Node]struct{}{}
+		var cover func(n *Node)
+		cover = func(n *Node) {
+			for _, d := range n.Deps {
+				if d == nil || owners[d] == 0 {
+					continue
+				}
+				if _, ok := below[d]; ok {
+					continue
+				}
+				below[d] = struct{}{}
+				cover(d)
+			}
+		}
+		for n, c := range owners {
+			if c > 1 {
+				cover(n)
+			}
+		}
+
+		added := false
+		for n, c := range owners {
+			// Zero values are cheap to spell out in every getter.
+			if _, ok := below[n]; c > 1 && !ok && !shared[n] && n.Provider.Kind != ProviderZero {
+				shared[n] = true
+				added = true
+			}
+		}
+		if !added {
+			return shared
+		}
+	}
+}
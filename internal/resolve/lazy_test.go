@@ -0,0 +1,115 @@
+package resolve
+
+import (
+	"go/types"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const lazySrc = `package app
+
+type Config struct{}
+type Conn struct{}
+type Heavy struct{}
+type A struct{}
+type B struct{}
+type C struct{}
+type Eager struct{}
+
+func NewConfig() *Config { return &Config{} }
+func NewConn() *Conn { return &Conn{} }
+func NewHeavy(*Config, *Conn) *Heavy { return &Heavy{} }
+func NewA(*Heavy) *A { return &A{} }
+func NewB(*Heavy, *Conn) *B { return &B{} }
+func NewC(*A) *C { return &C{} }
+func NewEager(*Config) *Eager { return &Eager{} }
+`
+
+func TestOrderLazyNodes(t *testing.T) {
+	pkg := checkSource(t, lazySrc)
+	providers := funcProviders(t, pkg, nil, "NewConfig", "NewConn", "NewHeavy", "NewA", "NewB", "NewC", "NewEager")
+
+	tests := []struct {
+		name   string
+		fields []string // "*T" for an eager field, "lazy *T" for a lazy one
+		eager  []string
+		shared [][]string
+		lazy   [][]string
+	}{
+		{
+			name:   "dependencies shared by lazy fields are built on first use",
+			fields: []string{"*Eager", "lazy *A", "lazy *B"},
+			eager:  []string{"NewConfig", "NewEager"},
+			shared: [][]string{{"NewConn"}, {"NewHeavy"}},
+			lazy:   [][]string{nil, {"NewA"}, {"NewB"}},
+		},
+		{
+			name:   "dependencies below a shared node are built by its getter",
+			fields: []string{"lazy *A", "lazy *C"},
+			shared: [][]string{{"NewConfig", "NewConn", "NewHeavy", "NewA"}},
+			lazy:   [][]string{nil, {"NewC"}},
+		},
+		{
+			name:   "dependencies of a single lazy field",
+			fields: []string{"*Eager", "lazy *B"},
+			eager:  []string{"NewConfig", "NewEager"},
+			lazy:   [][]string{nil, {"NewConn", "NewHeavy", "NewB"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []ContainerField
+			for _, spec := range tt.fields {
+				expr, lazy := spec, false
+				if rest, ok := strings.CutPrefix(spec, "lazy "); ok {
+					expr, lazy = rest, true
+				}
+				typ := lookupType(t, pkg, expr)
+				if lazy {
+					typ = types.NewSignatureType(nil, nil, nil, nil, types.NewTuple(types.NewVar(0, nil, "", typ)), false)
+				}
+				fields = append(fields, ContainerField{Name: expr[1:], Type: typ, Inject: InjectTag{Lazy: lazy}})
+			}
+
+			g, err := buildGraph(fields, providers, Options{})
+			if err != nil {
+				t.Fatalf("BuildGraph: %v", err)
+			}
+			ordered, err := OrderNodes(g)
+			if err != nil {
+				t.Fatalf("OrderNodes: %v", err)
+			}
+			if got := nodeNames(ordered); !slices.Equal(got, tt.eager) {
+				t.Errorf("OrderNodes = %v, want %v", got, tt.eager)
+			}
+
+			lazy, err := OrderLazyNodes(g)
+			if err != nil {
+				t.Fatalf("OrderLazyNodes: %v", err)
+			}
+			var shared [][]string
+			for _, s := range lazy.Shared {
+				if s.Nodes[len(s.Nodes)-1] != s.Node {
+					t.Errorf("shared getter of %s ends with %s", s.Node.Provider.Name, s.Nodes[len(s.Nodes)-1].Provider.Name)
+				}
+				shared = append(shared, nodeNames(s.Nodes))
+			}
+			if !slices.EqualFunc(shared, tt.shared, slices.Equal) {
+				t.Errorf("OrderLazyNodes shared = %v, want %v", shared, tt.shared)
+			}
+			var fieldNodes [][]string
+			for _, ns := range lazy.Fields {
+				if len(ns) == 0 {
+					fieldNodes = append(fieldNodes, nil)
+					continue
+				}
+				fieldNodes = append(fieldNodes, nodeNames(ns))
+			}
+			if !slices.EqualFunc(fieldNodes, tt.lazy, slices.Equal) {
+				t.Errorf("OrderLazyNodes fields = %v, want %v", fieldNodes, tt.lazy)
+			}
+		})
+	}
+}
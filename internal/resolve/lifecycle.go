@@ -0,0 +1,62 @@
+package resolve
+
+import "go/types"
+
+// Lifecycle names the methods that give a component a lifecycle.
+// Both methods must have the signature func(context.Context) error.
+type Lifecycle struct {
+	Start string
+	Stop  string
+}
+
+// LifecycleNodes returns the nodes whose values have a start or stop method, keeping the order of nodes.
+// Given nodes in topological order, the result is the order in which components must be started.
+func LifecycleNodes(nodes []*Node, lc Lifecycle) []*Node {
+	var out []*Node
+	for _, n := range nodes {
+		if n == nil || n.Provider == nil || isContainerField(n.Provider) {
+			// The fields of a composed container are managed by that container.
+			continue
+		}
+		if n.Provider.Kind == ProviderZero {
+			// A missing optional dependency is a zero value with nothing to start or stop.
+			continue
+		}
+		t := n.Provider.ResultType
+		if lc.Starts(t) || lc.Stops(t) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// Starts reports whether values of type t have the start method.
+func (lc Lifecycle) Starts(t types.Type) bool {
+	return hasLifecycleMethod(t, lc.Start)
+}
+
+// Stops reports whether values of type t have the stop method.
+func (lc Lifecycle) Stops(t types.Type) bool {
+	return hasLifecycleMethod(t, lc.Stop)
+}
+
+// hasLifecycleMethod reports whether values of type t have the method name
+// with the signature func(context.Context) error.
+func hasLifecycleMethod(t types.Type, name string) bool {
+	if name == "" {
+		return false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(t, false, nil, name)
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Variadic() {
+		return false
+	}
+	if sig.Params().Len() != 1 || typeKey(sig.Params().At(0).Type()) != contextTypeKey {
+		return false
+	}
+	return sig.Results().Len() == 1 && typeKey(sig.Results().At(0).Type()) == "error"
+}
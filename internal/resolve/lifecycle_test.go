@@ -0,0 +1,98 @@
+package resolve
+
+import (
+	"slices"
+	"testing"
+)
+
+const lifecycleSrc = `package app
+
+import "context"
+
+type Server struct{}
+
+func (s *Server) Start(context.Context) error { return nil }
+func (s *Server) Stop(context.Context) error  { return nil }
+
+type Worker struct{}
+
+func (w *Worker) Start(context.Context) error { return nil }
+func (w *Worker) Run(context.Context) error   { return nil }
+
+type Config struct{}
+
+func (c *Config) Stop() {}
+
+func NewServer(*Worker) *Server { return &Server{} }
+func NewWorker(*Config) *Worker { return &Worker{} }
+func NewConfig() *Config { return &Config{} }
+`
+
+func TestLifecycleNodes(t *testing.T) {
+	pkg := checkSource(t, lifecycleSrc)
+
+	tests := []struct {
+		name      string
+		fields    []fieldSpec
+		providers []string
+		lifecycle Lifecycle
+		want      []string
+	}{
+		{
+			name:      "components in dependency order",
+			fields:    []fieldSpec{{name: "Server", typ: "*Server"}, {name: "Worker", typ: "*Worker"}},
+			lifecycle: Lifecycle{Start: "Start", Stop: "Stop"},
+			want:      []string{"NewWorker", "NewServer"},
+		},
+		{
+			name:      "methods with another signature are ignored",
+			fields:    []fieldSpec{{name: "Config", typ: "*Config"}},
+			lifecycle: Lifecycle{Start: "Start", Stop: "Stop"},
+			want:      nil,
+		},
+		{
+			name: "optional component without a provider",
+			fields: []fieldSpec{
+				{name: "Worker", typ: "*Worker"},
+				{name: "Server", typ: "*Server", tag: InjectTag{Optional: true}},
+			},
+			providers: []string{"NewWorker", "NewConfig"},
+			lifecycle: Lifecycle{Start: "Start", Stop: "Stop"},
+			want:      []string{"NewWorker"},
+		},
+		{
+			name:      "only optional components without providers",
+			fields:    []fieldSpec{{name: "Server", typ: "*Server", tag: InjectTag{Optional: true}}},
+			providers: []string{},
+			lifecycle: Lifecycle{Start: "Start", Stop: "Stop"},
+			want:      nil,
+		},
+		{
+			name:      "custom method names",
+			fields:    []fieldSpec{{name: "Server", typ: "*Server"}},
+			lifecycle: Lifecycle{Start: "Run"},
+			want:      []string{"NewWorker"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := tt.providers
+			if names == nil {
+				names = []string{"NewServer", "NewWorker", "NewConfig"}
+			}
+			providers := funcProviders(t, pkg, nil, names...)
+			g, err := buildGraph(containerFields(t, pkg, tt.fields...), providers, Options{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ordered, err := OrderNodes(g)
+			if err != nil {
+				t.Fatalf("OrderNodes: %v", err)
+			}
+			if got := nodeNames(LifecycleNodes(ordered, tt.lifecycle)); !slices.Equal(got, tt.want) {
+				t.Errorf("LifecycleNodes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
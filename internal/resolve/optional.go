@@ -0,0 +1,66 @@
+package resolve
+
+import (
+	"errors"
+	"go/types"
+	"slices"
+)
+
+// errNoProvider reports that no provider exists for a binding.
+// Optional dependencies fall back to the zero value on this error only;
+// ambiguous bindings still fail.
+var errNoProvider = errors.New("no provider")
+
+// Missing is an optional dependency that has no provider and receives the zero value.
+type Missing struct {
+	Type types.Type
+	// Name is the qualifier of the binding, if any.
+	Name string
+	// RequiredBy describes the consumer: a container field or a provider.
+	RequiredBy string
+}
+
+// lookupOptional is lookup for an optional dependency of requiredBy.
+// When no provider exists, it returns a provider of the zero value and records the binding as missing.
+func (r *resolver) lookupOptional(t types.Type, name, requiredBy string) (*Provider, error) {
+	p, err := r.lookup(t, name)
+	if !errors.Is(err, errNoProvider) {
+		return p, err
+	}
+
+	key := bindingKey(t, name)
+	m := Missing{Type: t, Name: name, RequiredBy: requiredBy}
+	if !slices.ContainsFunc(r.missing, func(o Missing) bool {
+		return bindingKey(o.Type, o.Name) == key && o.RequiredBy == requiredBy
+	}) {
+		// Transient and scoped nodes resolve the same dependency again.
+		r.missing = append(r.missing, m)
+	}
+
+	if p, ok := r.zeros[key]; ok {
+		return p, nil
+	}
+	p = &Provider{
+		Kind:        ProviderZero,
+		Name:        zeroName(t, name),
+		NameWithPkg: "zero:" + bindingString(t, name),
+		ResultType:  t,
+		Qualifier:   name,
+	}
+	r.zeros[key] = p
+	return p, nil
+}
+
+// zeroName returns the variable name used for the zero value of a missing binding.
+func zeroName(t types.Type, name string) string {
+	if name != "" {
+		return lowerFirst(name)
+	}
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := types.Unalias(t).(*types.Named); ok {
+		return lowerFirst(named.Obj().Name())
+	}
+	return "zero"
+}
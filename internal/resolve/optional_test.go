@@ -0,0 +1,106 @@
+package resolve
+
+import (
+	"slices"
+	"testing"
+)
+
+const optionalSrc = `package app
+
+type Cache struct{}
+type DB struct{}
+type Server struct{}
+
+func NewCache() *Cache { return &Cache{} }
+func NewDB() *DB { return &DB{} }
+func NewReplica() *DB { return &DB{} }
+func NewServer(*DB, *Cache) *Server { return &Server{} }
+`
+
+func TestBuildGraphOptional(t *testing.T) {
+	pkg := checkSource(t, optionalSrc)
+
+	optionalCache := func(ps map[string]*Provider) {
+		ps["NewServer"].ParamTags = []InjectTag{{}, {Optional: true}}
+	}
+
+	tests := []struct {
+		name        string
+		providers   []string
+		configure   func(map[string]*Provider)
+		fields      []fieldSpec
+		want        [][]string
+		wantMissing []string
+		wantErr     string
+	}{
+		{
+			name:      "optional parameter with a provider",
+			providers: []string{"NewCache", "NewDB", "NewServer"},
+			configure: optionalCache,
+			fields:    []fieldSpec{{name: "Server", typ: "*Server"}},
+			want:      [][]string{{"NewServer", "NewDB", "NewCache"}},
+		},
+		{
+			name:        "optional parameter without a provider",
+			providers:   []string{"NewDB", "NewServer"},
+			configure:   optionalCache,
+			fields:      []fieldSpec{{name: "Server", typ: "*Server"}},
+			want:        [][]string{{"NewServer", "NewDB", "cache"}},
+			wantMissing: []string{"example.com/app.NewServer"},
+		},
+		{
+			name:      "optional field without a provider",
+			providers: []string{"NewDB"},
+			fields: []fieldSpec{
+				{name: "DB", typ: "*DB"},
+				{name: "Cache", typ: "*Cache", tag: InjectTag{Optional: true}},
+			},
+			want:        [][]string{{"NewDB"}, {"cache"}},
+			wantMissing: []string{"field Cache"},
+		},
+		{
+			name:      "optional qualified field is named after its qualifier",
+			providers: []string{"NewDB"},
+			fields: []fieldSpec{
+				{name: "Replica", typ: "*DB", tag: InjectTag{Name: "Replica", Optional: true}},
+			},
+			want:        [][]string{{"replica"}},
+			wantMissing: []string{"field Replica"},
+		},
+		{
+			name:      "ambiguous optional dependency",
+			providers: []string{"NewDB", "NewReplica"},
+			fields: []fieldSpec{
+				{name: "DB", typ: "*DB", tag: InjectTag{Optional: true}},
+			},
+			wantErr: "multiple providers for *example.com/app.DB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := funcProviders(t, pkg, tt.configure, tt.providers...)
+			g, err := buildGraph(containerFields(t, pkg, tt.fields...), providers, Options{})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := rootNames(g); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("roots = %v, want %v", got, tt.want)
+			}
+			var missing []string
+			for _, m := range g.Missing {
+				missing = append(missing, m.RequiredBy)
+			}
+			if !slices.Equal(missing, tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", missing, tt.wantMissing)
+			}
+			for _, n := range g.Roots {
+				for _, d := range append([]*Node{n}, n.Deps...) {
+					if d.Provider.Kind == ProviderZero && len(d.Deps) > 0 {
+						t.Errorf("zero value %s has dependencies", d.Provider.Name)
+					}
+				}
+			}
+		})
+	}
+}
@@ -2,45 +2,69 @@ package resolve
 
 import "fmt"
 
-// OrderProviders returns providers in topological order (dependencies first).
-// Providers may appear only once even if referenced multiple times by roots.
-func OrderProviders(g *Graph) ([]*Provider, error) {
+// OrderNodes returns the nodes built by the constructor in topological order (dependencies first).
+// Nodes may appear only once even if referenced multiple times by roots.
+// Nodes only needed by a lazy field are left out; see OrderLazyNodes.
+func OrderNodes(g *Graph) ([]*Node, error) {
 	if g == nil {
 		return nil, fmt.Errorf("resolve: graph is nil")
 	}
 
-	visited := map[*Provider]struct{}{}
-	onstack := map[*Provider]struct{}{}
-	var out []*Provider
+	eager := eagerNodes(g)
+	var starts []*Node
+	for i, r := range g.Roots {
+		if !g.isLazy(i) {
+			starts = append(starts, r)
+		}
+	}
+	// Args and the context are parameters of the constructor even if only lazy fields need them.
+	starts = append(starts, g.Args...)
+	starts = append(starts, g.Context)
+
+	return orderFrom(starts, func(n *Node) bool {
+		_, ok := eager[n]
+		return ok
+	})
+}
+
+// orderFrom returns the nodes reachable from starts for which keep returns true,
+// in topological order (dependencies first).
+// The dependencies of a node for which keep returns false are not visited.
+func orderFrom(starts []*Node, keep func(*Node) bool) ([]*Node, error) {
+	visited := map[*Node]struct{}{}
+	onstack := map[*Node]struct{}{}
+	var out []*Node
 
 	var visit func(n *Node) error
 	visit = func(n *Node) error {
 		if n == nil || n.Provider == nil {
 			return nil
 		}
-
-		p := n.Provider
-		if _, ok := onstack[p]; ok {
-			return fmt.Errorf("resolve: circular dependency detected at %s", providerString(p))
+		if _, ok := onstack[n]; ok {
+			return fmt.Errorf("resolve: circular dependency detected at %s", providerString(n.Provider))
+		}
+		if _, ok := visited[n]; ok {
+			return nil
 		}
-		if _, ok := visited[p]; ok {
+		if !keep(n) {
+			visited[n] = struct{}{}
 			return nil
 		}
 
-		onstack[p] = struct{}{}
+		onstack[n] = struct{}{}
 		for _, d := range n.Deps {
 			if err := visit(d); err != nil {
 				return err
 			}
 		}
-		delete(onstack, p)
+		delete(onstack, n)
 
-		visited[p] = struct{}{}
-		out = append(out, p)
+		visited[n] = struct{}{}
+		out = append(out, n)
 		return nil
 	}
 
-	for _, r := range g.Roots {
+	for _, r := range starts {
 		if err := visit(r); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,57 @@
+package resolve
+
+import (
+	"fmt"
+	"go/types"
+	"slices"
+	"strings"
+)
+
+// profileProviders returns the providers without a profile and the providers of profile,
+// then the providers of the other profiles.
+func profileProviders(providers []*Provider, profile string) (active, inactive []*Provider) {
+	for _, p := range providers {
+		if len(p.Profiles) > 0 && !slices.Contains(p.Profiles, profile) {
+			inactive = append(inactive, p)
+			continue
+		}
+		active = append(active, p)
+	}
+	return active, inactive
+}
+
+// preferProfiled returns the candidates of the active profile if there are any, or all candidates.
+// Candidates are filtered by profileProviders, so a candidate with a profile belongs to the active one.
+func preferProfiled(cands []*Provider) []*Provider {
+	var profiled []*Provider
+	for _, p := range cands {
+		if len(p.Profiles) > 0 {
+			profiled = append(profiled, p)
+		}
+	}
+	if len(profiled) == 0 {
+		return cands
+	}
+	return profiled
+}
+
+// inactiveHint names the profiles that would provide the binding (t, name), or returns "".
+func (r *resolver) inactiveHint(t types.Type, name string) string {
+	key := bindingKey(t, name)
+	var profiles []string
+	for _, p := range r.inactive {
+		if bindingKey(p.ResultType, p.Qualifier) != key {
+			continue
+		}
+		for _, profile := range p.Profiles {
+			if !slices.Contains(profiles, profile) {
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+	if len(profiles) == 0 {
+		return ""
+	}
+	slices.Sort(profiles)
+	return fmt.Sprintf(" (provided in profiles %s only)", strings.Join(profiles, ", "))
+}
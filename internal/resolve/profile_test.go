@@ -0,0 +1,80 @@
+package resolve
+
+import (
+	"slices"
+	"testing"
+)
+
+const profileSrc = `package app
+
+type Mailer interface{ Send() }
+type smtp struct{}
+type Server struct{}
+
+func (smtp) Send() {}
+
+func NewSMTP() Mailer { return smtp{} }
+func NewFakeMailer() Mailer { return smtp{} }
+func NewLogMailer() Mailer { return smtp{} }
+func NewServer(Mailer) *Server { return &Server{} }
+`
+
+func TestBuildGraphProfiles(t *testing.T) {
+	pkg := checkSource(t, profileSrc)
+
+	tests := []struct {
+		name     string
+		profiles map[string][]string
+		profile  string
+		want     [][]string
+		wantErr  string
+	}{
+		{
+			name:     "providers without a profile by default",
+			profiles: map[string][]string{"NewFakeMailer": {"local"}, "NewLogMailer": {"test"}},
+			want:     [][]string{{"NewServer", "NewSMTP"}},
+		},
+		{
+			name:     "providers of the profile take precedence",
+			profiles: map[string][]string{"NewFakeMailer": {"local", "test"}, "NewLogMailer": {"debug"}},
+			profile:  "test",
+			want:     [][]string{{"NewServer", "NewFakeMailer"}},
+		},
+		{
+			name:     "providers of other profiles are not candidates",
+			profiles: map[string][]string{"NewFakeMailer": {"local"}, "NewLogMailer": {"test"}},
+			profile:  "test",
+			want:     [][]string{{"NewServer", "NewLogMailer"}},
+		},
+		{
+			name:     "binding provided in other profiles only",
+			profiles: map[string][]string{"NewSMTP": {"prod"}, "NewFakeMailer": {"local"}, "NewLogMailer": {"test"}},
+			wantErr:  "no provider for example.com/app.Mailer (provided in profiles local, prod, test only)",
+		},
+		{
+			name:     "providers of the same profile are ambiguous",
+			profiles: map[string][]string{"NewFakeMailer": {"local"}, "NewLogMailer": {"local"}},
+			profile:  "local",
+			wantErr:  "multiple providers for example.com/app.Mailer: example.com/app.NewFakeMailer, example.com/app.NewLogMailer;",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure := func(ps map[string]*Provider) {
+				for name, profiles := range tt.profiles {
+					ps[name].Profiles = profiles
+				}
+			}
+			providers := funcProviders(t, pkg, configure, "NewSMTP", "NewFakeMailer", "NewLogMailer", "NewServer")
+			fields := containerFields(t, pkg, fieldSpec{name: "Server", typ: "*Server"})
+			g, err := buildGraph(fields, providers, Options{Profile: tt.profile})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := rootNames(g); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("roots = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package resolve
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScopedOverride is a `with:<TypeExpr>=<provider>` directive of a container field.
+type ScopedOverride struct {
+	// Type is the overridden type, qualified by package name or by package path.
+	Type string
+	// Provider selects the provider of Type within the subtree of the field.
+	Provider string
+}
+
+// UnusedOverride is a scoped override that no dependency of its field resolved to.
+type UnusedOverride struct {
+	Field    string
+	Override ScopedOverride
+}
+
+// scope holds the `with` overrides of the field being resolved.
+//
+// Nodes that depend on an override, directly or transitively, are private to the field.
+// The other nodes resolved within the scope are shared with the rest of the graph.
+type scope struct {
+	field string
+	with  []ScopedOverride
+	// keys are the binding keys of the overrides, aligned with with.
+	keys []string
+	// overrides maps a binding key to the provider selected by a `with` directive.
+	overrides map[string]*Provider
+	// used records the binding keys of the overrides that a dependency resolved to.
+	used map[string]bool
+	// hit reports whether the last lookup returned an override.
+	hit bool
+	// nodes are the nodes resolved within the scope.
+	nodes map[*Provider]*Node
+	// private are the nodes that depend on an override.
+	private map[*Node]bool
+}
+
+// newScope resolves the `with` directives of f.
+// The provider of a directive must return the named type.
+func (r *resolver) newScope(f ContainerField) (*scope, error) {
+	s := &scope{
+		field:     f.Name,
+		with:      f.Inject.With,
+		overrides: map[string]*Provider{},
+		used:      map[string]bool{},
+		nodes:     map[*Provider]*Node{},
+		private:   map[*Node]bool{},
+	}
+	for _, w := range f.Inject.With {
+		ps, err := lookupProviderByDirective(r.byName, w.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: with:%s=%s: %w", f.Name, w.Type, w.Provider, err)
+		}
+
+		var found []*Provider
+		for _, p := range ps {
+			if p.Generic != nil || isContainerField(p) {
+				continue
+			}
+			if typeString(p.ResultType) == w.Type || shortTypeString(p.ResultType) == w.Type {
+				found = append(found, p)
+			}
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("field %s: with:%s=%s: no provider %s returns %s", f.Name, w.Type, w.Provider, w.Provider, w.Type)
+		}
+		if len(found) > 1 {
+			names := make([]string, 0, len(found))
+			for _, p := range found {
+				names = append(names, providerString(p))
+			}
+			return nil, fmt.Errorf("field %s: with:%s=%s is ambiguous: %s", f.Name, w.Type, w.Provider, strings.Join(names, ", "))
+		}
+		key := bindingKey(found[0].ResultType, "")
+		if existing, ok := s.overrides[key]; ok {
+			return nil, fmt.Errorf("field %s: %s is overridden by both %s and %s", f.Name, typeString(found[0].ResultType), providerString(existing), providerString(found[0]))
+		}
+		s.overrides[key] = found[0]
+		s.keys = append(s.keys, key)
+	}
+	return s, nil
+}
+
+// scoped returns the override of the binding key in the active scope, if any.
+func (r *resolver) scoped(key string) (*Provider, bool) {
+	if r.scope == nil {
+		return nil, false
+	}
+	p, ok := r.scope.overrides[key]
+	if ok {
+		r.scope.used[key] = true
+		r.scope.hit = true
+	}
+	return p, ok
+}
+
+// resolveScoped is resolveProvider within the active scope.
+// A node that does not depend on an override is the node shared by the rest of the graph.
+func (r *resolver) resolveScoped(p *Provider) (*Node, error) {
+	s := r.scope
+	if n, ok := s.nodes[p]; ok {
+		return n, nil
+	}
+
+	n, err := r.buildNode(p)
+	if err != nil {
+		return nil, err
+	}
+	if !s.private[n] {
+		if shared, ok := r.nodes[p]; ok {
+			n = shared
+		} else {
+			r.nodes[p] = n
+		}
+	}
+	s.nodes[p] = n
+	return n, nil
+}
+
+// unused returns the overrides that no lookup within s returned.
+func (s *scope) unused() []UnusedOverride {
+	var out []UnusedOverride
+	for i, key := range s.keys {
+		if !s.used[key] {
+			out = append(out, UnusedOverride{Field: s.field, Override: s.with[i]})
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package resolve
+
+import "testing"
+
+const scopeSrc = `package app
+
+type Database interface{ Query() }
+
+type db struct{}
+
+func (db) Query() {}
+
+func NewWriterDB() Database { return db{} }
+func NewReaderDB() Database { return db{} }
+
+type Repo struct{}
+type Clock struct{}
+type Users struct{}
+type Report struct{}
+
+func NewRepo(Database) *Repo { return &Repo{} }
+func NewClock() *Clock { return &Clock{} }
+func NewUsers(*Repo, *Clock) *Users { return &Users{} }
+func NewReport(*Repo, *Clock) *Report { return &Report{} }
+`
+
+func TestBuildGraphScopedOverrides(t *testing.T) {
+	pkg := checkSource(t, scopeSrc)
+	providers := funcProviders(t, pkg, nil, "NewWriterDB", "NewReaderDB", "NewRepo", "NewClock", "NewUsers", "NewReport")
+
+	override := ContainerField{Name: "_", Type: lookupType(t, pkg, "Database"), Inject: InjectTag{Provider: "app.NewWriterDB"}}
+	users := ContainerField{Name: "Users", Type: lookupType(t, pkg, "*Users")}
+	repo := ContainerField{Name: "Repo", Type: lookupType(t, pkg, "*Repo")}
+	report := ContainerField{
+		Name:   "Report",
+		Type:   lookupType(t, pkg, "*Report"),
+		Inject: InjectTag{With: []ScopedOverride{{Type: "app.Database", Provider: "app.NewReaderDB"}}},
+	}
+
+	tests := []struct {
+		name   string
+		fields []ContainerField
+	}{
+		{name: "scoped field last", fields: []ContainerField{override, users, repo, report}},
+		{name: "scoped field first", fields: []ContainerField{override, report, repo, users}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := buildGraph(tt.fields, providers, Options{})
+			if err != nil {
+				t.Fatalf("BuildGraph: %v", err)
+			}
+			if len(g.Unused) > 0 {
+				t.Errorf("Unused = %v, want none", g.Unused)
+			}
+
+			roots := map[string]*Node{}
+			var i int
+			for _, f := range tt.fields {
+				if f.Resolvable() {
+					roots[f.Name] = g.Roots[i]
+					i++
+				}
+			}
+			u, r := roots["Users"], roots["Report"]
+
+			// Users keeps the container-wide override; Report's repository reads from its own database.
+			if got := u.Deps[0].Deps[0].Provider.Name; got != "NewWriterDB" {
+				t.Errorf("Users database = %s, want NewWriterDB", got)
+			}
+			if got := r.Deps[0].Deps[0].Provider.Name; got != "NewReaderDB" {
+				t.Errorf("Report database = %s, want NewReaderDB", got)
+			}
+			if u.Deps[0] == r.Deps[0] {
+				t.Error("Users and Report share the repository built with different databases")
+			}
+			if roots["Repo"] != u.Deps[0] {
+				t.Error("Repo is not the repository of Users")
+			}
+			// Nodes that do not depend on the override are shared.
+			if u.Deps[1] != r.Deps[1] {
+				t.Error("Users and Report do not share the clock")
+			}
+
+			ordered, err := OrderNodes(g)
+			if err != nil {
+				t.Fatalf("OrderNodes: %v", err)
+			}
+			got := nodeNames(ordered)
+			for name, want := range map[string]int{"NewWriterDB": 1, "NewReaderDB": 1, "NewRepo": 2, "NewClock": 1} {
+				if n := countOf(got, name); n != want {
+					t.Errorf("OrderNodes = %v: %s is built %d times, want %d", got, name, n, want)
+				}
+			}
+		})
+	}
+}
+
+func countOf(names []string, name string) int {
+	var n int
+	for _, s := range names {
+		if s == name {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,16 @@
+package resolve
+
+// preferTests returns the candidates declared in _test.go files if there are any, or all candidates.
+// Test providers are fakes that replace the production providers of their bindings in test containers.
+func preferTests(cands []*Provider) []*Provider {
+	var fakes []*Provider
+	for _, p := range cands {
+		if p.Test {
+			fakes = append(fakes, p)
+		}
+	}
+	if len(fakes) == 0 {
+		return cands
+	}
+	return fakes
+}
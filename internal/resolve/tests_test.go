@@ -0,0 +1,85 @@
+package resolve
+
+import (
+	"slices"
+	"testing"
+)
+
+const testsSrc = `package app
+
+type Clock interface{ Now() int64 }
+type clock struct{}
+type Server struct{}
+
+func (clock) Now() int64 { return 0 }
+
+func NewClock() Clock { return clock{} }
+func NewFakeClock() Clock { return clock{} }
+func newFakeClock() Clock { return clock{} }
+func NewServer(Clock) *Server { return &Server{} }
+`
+
+func TestBuildGraphTestProviders(t *testing.T) {
+	pkg := checkSource(t, testsSrc)
+
+	tests := []struct {
+		name    string
+		fake    string // the test provider, if any
+		fakePkg string // the package of the test provider, example.com/app by default
+		pkgPath string // the package of the container, example.com/app by default
+		want    [][]string
+	}{
+		{
+			name: "production providers without test providers",
+			want: [][]string{{"NewServer", "NewClock"}},
+		},
+		{
+			name: "test providers replace production providers",
+			fake: "NewFakeClock",
+			want: [][]string{{"NewServer", "NewFakeClock"}},
+		},
+		{
+			name:    "test providers of other packages are not visible",
+			fake:    "NewFakeClock",
+			fakePkg: "example.com/other",
+			want:    [][]string{{"NewServer", "NewClock"}},
+		},
+		{
+			name:    "exported test providers are visible to the external test package",
+			fake:    "NewFakeClock",
+			pkgPath: testPkgPath + "_test",
+			want:    [][]string{{"NewServer", "NewFakeClock"}},
+		},
+		{
+			name:    "unexported test providers are not visible to the external test package",
+			fake:    "newFakeClock",
+			pkgPath: testPkgPath + "_test",
+			want:    [][]string{{"NewServer", "NewClock"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := []string{"NewClock", "NewServer"}
+			if tt.fake != "" {
+				names = append(names, tt.fake)
+			}
+			providers := funcProviders(t, pkg, func(ps map[string]*Provider) {
+				if p, ok := ps[tt.fake]; ok {
+					p.Test = true
+					if tt.fakePkg != "" {
+						p.PkgPath = tt.fakePkg
+					}
+				}
+			}, names...)
+			fields := containerFields(t, pkg, fieldSpec{name: "Server", typ: "*Server"})
+			g, err := buildGraph(fields, providers, Options{PkgPath: tt.pkgPath})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := rootNames(g); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("roots = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
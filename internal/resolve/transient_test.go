@@ -0,0 +1,95 @@
+package resolve
+
+import "testing"
+
+const transientSrc = `package app
+
+type Buffer struct{}
+type A struct{}
+type B struct{}
+
+func NewBuffer() *Buffer { return &Buffer{} }
+func NewA(*Buffer) *A { return &A{} }
+func NewB(*Buffer) *B { return &B{} }
+`
+
+func TestBuildGraphTransient(t *testing.T) {
+	pkg := checkSource(t, transientSrc)
+
+	tests := []struct {
+		name      string
+		configure func(map[string]*Provider)
+		fields    []fieldSpec
+		shared    bool // whether every dependent receives the same Buffer node
+	}{
+		{
+			name:   "providers are shared by default",
+			fields: []fieldSpec{{name: "A", typ: "*A"}, {name: "B", typ: "*B"}},
+			shared: true,
+		},
+		{
+			name:      "transient provider",
+			configure: func(ps map[string]*Provider) { ps["NewBuffer"].Transient = true },
+			fields:    []fieldSpec{{name: "A", typ: "*A"}, {name: "B", typ: "*B"}},
+		},
+		{
+			name:      "transient parameter",
+			configure: func(ps map[string]*Provider) { ps["NewA"].ParamTags = []InjectTag{{Transient: true}} },
+			fields:    []fieldSpec{{name: "A", typ: "*A"}, {name: "B", typ: "*B"}},
+		},
+		{
+			name:   "transient field",
+			fields: []fieldSpec{{name: "Buffer", typ: "*Buffer", tag: InjectTag{Transient: true}}, {name: "A", typ: "*A"}},
+		},
+		{
+			name:      "args are always shared",
+			configure: func(ps map[string]*Provider) { ps["NewBuffer"].Transient = true },
+			fields: []fieldSpec{
+				{name: "Buffer", typ: "*Buffer", tag: InjectTag{Arg: true}},
+				{name: "A", typ: "*A"},
+				{name: "B", typ: "*B"},
+			},
+			shared: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := funcProviders(t, pkg, tt.configure, "NewBuffer", "NewA", "NewB")
+			g, err := buildGraph(containerFields(t, pkg, tt.fields...), providers, Options{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var buffers []*Node
+			for _, r := range g.Roots {
+				if r.Provider.Name == "NewBuffer" || r.Provider.Kind == ProviderArg {
+					buffers = append(buffers, r)
+					continue
+				}
+				buffers = append(buffers, r.Deps[0])
+			}
+			shared := true
+			for _, b := range buffers[1:] {
+				shared = shared && b == buffers[0]
+			}
+			if shared != tt.shared {
+				t.Errorf("shared = %v, want %v", shared, tt.shared)
+			}
+
+			nodes, err := OrderNodes(g)
+			if err != nil {
+				t.Fatalf("OrderNodes: %v", err)
+			}
+			var built int
+			for _, n := range nodes {
+				if n.Provider.Name == "NewBuffer" {
+					built++
+				}
+			}
+			if !tt.shared && built < 2 {
+				t.Errorf("NewBuffer is called %d times, want once per dependent", built)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package resolve
+
+import (
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// typeKey returns a key that is equal for identical types.
+//
+// Unlike types.TypeString, the key ignores parameter and result names of function types
+// and resolves aliases, so `func(ctx context.Context) error` and an alias of
+// `func(context.Context) error` index the same providers.
+func typeKey(t types.Type) string {
+	var b strings.Builder
+	writeTypeKey(&b, t)
+	return b.String()
+}
+
+func writeTypeKey(b *strings.Builder, t types.Type) {
+	switch t := types.Unalias(t).(type) {
+	case *types.Named:
+		if pkg := t.Obj().Pkg(); pkg != nil {
+			b.WriteString(pkg.Path())
+			b.WriteByte('.')
+		}
+		b.WriteString(t.Obj().Name())
+		if targs := t.TypeArgs(); targs.Len() > 0 {
+			b.WriteByte('[')
+			for i := 0; i < targs.Len(); i++ {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				writeTypeKey(b, targs.At(i))
+			}
+			b.WriteByte(']')
+		}
+	case *types.Pointer:
+		b.WriteByte('*')
+		writeTypeKey(b, t.Elem())
+	case *types.Slice:
+		b.WriteString("[]")
+		writeTypeKey(b, t.Elem())
+	case *types.Array:
+		b.WriteString("[" + strconv.FormatInt(t.Len(), 10) + "]")
+		writeTypeKey(b, t.Elem())
+	case *types.Map:
+		b.WriteString("map[")
+		writeTypeKey(b, t.Key())
+		b.WriteByte(']')
+		writeTypeKey(b, t.Elem())
+	case *types.Chan:
+		switch t.Dir() {
+		case types.SendRecv:
+			b.WriteString("chan ")
+		case types.SendOnly:
+			b.WriteString("chan<- ")
+		case types.RecvOnly:
+			b.WriteString("<-chan ")
+		}
+		writeTypeKey(b, t.Elem())
+	case *types.Signature:
+		b.WriteString("func")
+		writeSignatureKey(b, t)
+	case *types.Interface:
+		b.WriteString("interface{")
+		for i := 0; i < t.NumMethods(); i++ {
+			if i > 0 {
+				b.WriteByte(';')
+			}
+			m := t.Method(i)
+			if !m.Exported() && m.Pkg() != nil {
+				// Unexported method names are distinct per package.
+				b.WriteString(m.Pkg().Path() + ".")
+			}
+			b.WriteString(m.Name())
+			writeSignatureKey(b, m.Type().(*types.Signature))
+		}
+		b.WriteByte('}')
+	case *types.Struct:
+		b.WriteString("struct{")
+		for i := 0; i < t.NumFields(); i++ {
+			if i > 0 {
+				b.WriteByte(';')
+			}
+			f := t.Field(i)
+			if f.Embedded() {
+				b.WriteString("embedded ")
+			} else {
+				if !f.Exported() && f.Pkg() != nil {
+					b.WriteString(f.Pkg().Path() + ".")
+				}
+				b.WriteString(f.Name() + " ")
+			}
+			writeTypeKey(b, f.Type())
+			if tag := t.Tag(i); tag != "" {
+				b.WriteString(" " + strconv.Quote(tag))
+			}
+		}
+		b.WriteByte('}')
+	default:
+		// Basic types and type parameters print the same way wherever they appear.
+		b.WriteString(types.TypeString(t, func(p *types.Package) string {
+			if p == nil {
+				return ""
+			}
+			return p.Path()
+		}))
+	}
+}
+
+// writeSignatureKey writes the parameter and result types of sig without their names.
+func writeSignatureKey(b *strings.Builder, sig *types.Signature) {
+	writeTupleKey(b, sig.Params(), sig.Variadic())
+	if sig.Results().Len() > 0 {
+		b.WriteByte(' ')
+		writeTupleKey(b, sig.Results(), false)
+	}
+}
+
+func writeTupleKey(b *strings.Builder, tup *types.Tuple, variadic bool) {
+	b.WriteByte('(')
+	for i := 0; i < tup.Len(); i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		t := tup.At(i).Type()
+		if variadic && i == tup.Len()-1 {
+			b.WriteString("...")
+			if s, ok := t.(*types.Slice); ok {
+				t = s.Elem()
+			}
+		}
+		writeTypeKey(b, t)
+	}
+	b.WriteByte(')')
+}
@@ -0,0 +1,68 @@
+package resolve
+
+import (
+	"slices"
+	"testing"
+)
+
+const compositeSrc = `package app
+
+import "context"
+
+type Route struct{}
+type Server struct{}
+
+type CheckFunc = func(context.Context) error
+type Check func(context.Context) error
+
+func NewRoutes() []*Route { return nil }
+func NewCheck() func(ctx context.Context) error { return nil }
+func NewTable() map[string]int { return nil }
+func NewEvents() <-chan int { return nil }
+func NewServer(routes []*Route, check func(context.Context) error, table map[string]int) *Server { return &Server{} }
+`
+
+func TestBuildGraphCompositeResults(t *testing.T) {
+	pkg := checkSource(t, compositeSrc)
+
+	tests := []struct {
+		name    string
+		field   fieldSpec
+		want    [][]string
+		wantErr string
+	}{
+		{
+			name:  "composite and function parameters",
+			field: fieldSpec{name: "Server", typ: "*Server"},
+			want:  [][]string{{"NewServer", "NewRoutes", "NewCheck", "NewTable"}},
+		},
+		{
+			name:  "alias of a function type",
+			field: fieldSpec{name: "Check", typ: "CheckFunc"},
+			want:  [][]string{{"NewCheck"}},
+		},
+		{
+			name:  "slice field",
+			field: fieldSpec{name: "Routes", typ: "[]*Route"},
+			want:  [][]string{{"NewRoutes"}},
+		},
+		{
+			name:    "named function type is distinct from its underlying type",
+			field:   fieldSpec{name: "Check", typ: "Check"},
+			wantErr: "no provider for example.com/app.Check",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := funcProviders(t, pkg, nil, "NewRoutes", "NewCheck", "NewTable", "NewEvents", "NewServer")
+			g, err := buildGraph(containerFields(t, pkg, tt.field), providers, Options{})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := rootNames(g); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("roots = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
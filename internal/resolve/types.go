@@ -3,8 +3,21 @@ package resolve
 import "go/types"
 
 // Graph represents a resolved dependency graph.
+//
+// Roots are aligned with the resolvable container fields, in declaration order.
+// Lazy is aligned with Roots and reports whether the root belongs to a lazy field.
+// Args are the nodes of `inject:"arg"` fields, in declaration order;
+// they become the parameters of the generated constructor.
+// Context is the node of the context.Context parameter when Options.Context is set.
+// Missing lists the optional dependencies without a provider, which receive zero values.
+// Unused lists the `with` overrides of fields that no dependency resolved to.
 type Graph struct {
-	Roots []*Node
+	Roots   []*Node
+	Lazy    []bool
+	Args    []*Node
+	Context *Node
+	Missing []Missing
+	Unused  []UnusedOverride
 }
 
 // Node represents a node in the resolved dependency graph.
@@ -12,11 +25,38 @@ type Graph struct {
 // Each node corresponds to exactly one Provider.
 // Nodes may be shared across the graph to represent a DAG
 // (i.e. the same Provider can be depended on by multiple parents).
+// Deps are aligned with Provider.Params.
 type Node struct {
 	Provider *Provider
 	Deps     []*Node
 }
 
+// ProviderKind describes how a Provider produces its value.
+type ProviderKind int
+
+const (
+	// ProviderFunc calls a constructor function.
+	ProviderFunc ProviderKind = iota
+	// ProviderArg reads a parameter of the generated constructor.
+	// Its Name is the parameter name.
+	ProviderArg
+	// ProviderGroup collects the values of group members into a slice or a map.
+	// Its Name is the group name, and its Members (and Keys, for maps) are aligned with Params.
+	ProviderGroup
+	// ProviderZero yields the zero value of an optional dependency without a provider.
+	ProviderZero
+	// ProviderMethod calls a method of a module value, which is its first parameter.
+	// Its Name is qualified by the module type name (e.g. DBModule.NewPool).
+	ProviderMethod
+	// ProviderStruct builds a struct literal, assigning each parameter to the field named in Fields.
+	ProviderStruct
+	// ProviderField selects a field of a struct value, which is its only parameter.
+	// Its Name is qualified by the struct type name (e.g. App.DB).
+	ProviderField
+	// ProviderVar reads a package-level variable.
+	ProviderVar
+)
+
 // Provider represents a constructor function that can produce a value
 // for dependency injection.
 //
@@ -24,13 +64,63 @@ type Node struct {
 // It is treated as immutable during resolution and is shared across the
 // dependency graph for cycle detection and override resolution.
 type Provider struct {
+	Kind        ProviderKind
 	PkgPath     string
 	Name        string
 	NameWithPkg string
 	ResultType  types.Type
 	ReturnError bool
-	Params      []types.Type
-	Position    string
+	// ReturnCleanup reports whether the provider returns a cleanup func() after its value.
+	ReturnCleanup bool
+	Params        []types.Type
+	// Qualifier is the binding name of the result (empty for the default binding).
+	Qualifier string
+	// ParamTags holds per-parameter directives, aligned with Params.
+	ParamTags []InjectTag
+	// Groups lists the groups the provider contributes its value to.
+	Groups []Group
+	// Members are the providers collected by a ProviderGroup, in group order.
+	Members []*Provider
+	// Keys are the map keys of Members when a ProviderGroup builds a map.
+	Keys []string
+	// Generic is the signature of a generic function, or nil.
+	// Generic providers are instantiated for the requested types and never used directly.
+	Generic *types.Signature
+	// TypeArgs are the type arguments of an instantiated generic provider.
+	TypeArgs []types.Type
+	// Fields are the struct fields assigned from Params by a ProviderStruct.
+	Fields []string
+	// Var reports whether the provider is a package-level variable, read or called.
+	// Unexported variables are only visible to containers in the same package.
+	Var bool
+	// Container reports whether the provider is the generated constructor of a container
+	// or one of its fields (see ContainerProviders).
+	Container bool
+	// Transient gives every dependent its own value instead of sharing one.
+	Transient bool
+	// Profiles restricts the provider to the named profiles (see Options.Profile).
+	Profiles []string
+	// Test reports whether the provider is declared in a _test.go file.
+	// Test providers take precedence over the other providers of their bindings.
+	Test     bool
+	Position string
+}
+
+// Group is a group membership of a provider.
+type Group struct {
+	Name string
+	// Priority orders the members of a group; higher priorities come first.
+	Priority int
+	// Key is the entry key of the member when the group is collected into a map.
+	Key string
+}
+
+// paramTag returns the directives for the i-th parameter.
+func (p *Provider) paramTag(i int) InjectTag {
+	if i < len(p.ParamTags) {
+		return p.ParamTags[i]
+	}
+	return InjectTag{}
 }
 
 // ContainerField represents an injectable field in a Container struct.
@@ -49,4 +139,93 @@ type InjectTag struct {
 	// Provider selects a specific provider function by name.
 	// Example: `inject:"provider:service.NewUser"`
 	Provider string
+
+	// Name selects a qualified binding of the field type.
+	// Example: `inject:"name:replica"`
+	Name string
+
+	// Bind selects the concrete provider result type that satisfies an interface field.
+	// Example: `inject:"bind:*postgres.Repo"`
+	Bind string
+
+	// Arg turns the field into a parameter of the generated constructor.
+	// Example: `inject:"arg"`
+	Arg bool
+
+	// Lifecycle marks a []any field that receives the lifecycle components
+	// managed by the generated Start and Stop methods. The field is optional.
+	// Example: `inject:"lifecycle"`
+	Lifecycle bool
+
+	// Lazy defers building the field's private subgraph until first use.
+	// The field must be of type func() T or func() (T, error).
+	// Example: `inject:"lazy"`
+	Lazy bool
+
+	// Proxy makes a lazy interface field hold a generated proxy
+	// that builds the implementation on the first method call.
+	// Example: `inject:"lazy:proxy"`
+	Proxy bool
+
+	// Group collects every provider annotated with `//injector:group <name>` into a slice,
+	// or into a map keyed by the `key` option of the annotations.
+	// Example: `inject:"group:routes"`
+	Group string
+
+	// Optional resolves the field to the zero value when no provider exists,
+	// or allows a group to have no members.
+	// Example: `inject:"optional"`, `inject:"group:routes,optional"`
+	Optional bool
+
+	// Module makes the exported methods of the field type providers.
+	// Example: `inject:"module"`
+	Module bool
+
+	// Struct builds the field by assigning its `inject`-tagged fields from the graph.
+	// StructAll assigns every exported field instead.
+	// Example: `inject:"struct"`, `inject:"struct:all"`
+	Struct    bool
+	StructAll bool
+
+	// Fields makes the exported fields of the field type providers.
+	// Example: `inject:"fields"`
+	Fields bool
+
+	// Transient builds a value for this field or parameter alone instead of sharing it.
+	// Example: `inject:"transient"`
+	Transient bool
+
+	// Compose reuses the fields of the container the field holds:
+	// they take precedence over the other providers of their types (see ContainerProviders).
+	// Example: `inject:"compose"`
+	Compose bool
+
+	// With overrides bindings within the dependency subtree of the field only.
+	// Example: `inject:"with:infra.Database=infra.NewReaderDatabase"`
+	With []ScopedOverride
+}
+
+// Resolvable reports whether the field is resolved to a graph root.
+// Blank fields only configure resolution, and the lifecycle field is filled by the generator.
+func (f ContainerField) Resolvable() bool {
+	return f.Name != "_" && !f.Inject.Lifecycle
+}
+
+// Options configures graph resolution.
+type Options struct {
+	// ImplicitBindings lets an interface be satisfied by the only provider result type
+	// implementing it when no provider returns the interface itself.
+	ImplicitBindings bool
+
+	// Context adds a context.Context parameter to the generated constructor
+	// and passes it to every provider parameter of that type.
+	Context bool
+
+	// PkgPath is the package of the container.
+	// Unexported variable providers of other packages are not candidates.
+	PkgPath string
+
+	// Profile is the active profile. Providers of other profiles are not candidates,
+	// and providers of the active profile take precedence over providers without a profile.
+	Profile string
 }
@@ -0,0 +1,267 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const annotationPrefix = "//injector:"
+
+// annotation represents a `//injector:<verb> <args>` comment directive.
+type annotation struct {
+	Verb string
+	Args string
+}
+
+// parseAnnotations extracts injector annotations from a doc comment group.
+// Comments that do not start with the `//injector:` prefix are ignored.
+func parseAnnotations(doc *ast.CommentGroup) []annotation {
+	if doc == nil {
+		return nil
+	}
+
+	var out []annotation
+	for _, c := range doc.List {
+		if c == nil || !strings.HasPrefix(c.Text, annotationPrefix) {
+			continue
+		}
+		body := strings.TrimSpace(strings.TrimPrefix(c.Text, annotationPrefix))
+		verb, args, _ := strings.Cut(body, " ")
+		out = append(out, annotation{
+			Verb: verb,
+			Args: strings.TrimSpace(args),
+		})
+	}
+	return out
+}
+
+// providerAnnotations is the parsed set of annotations attached to a provider.
+type providerAnnotations struct {
+	// Provide is set by `//injector:provide`.
+	Provide bool
+	// Ignore is set by `//injector:ignore`.
+	Ignore bool
+	// Qualifier is set by `//injector:name <qualifier>`.
+	Qualifier string
+	// Params maps a parameter name to the directives set by
+	// `//injector:param <name> <directives>`.
+	Params map[string]InjectTag
+	// Groups are set by `//injector:group <name> [priority=N] [key=K]`.
+	Groups []GroupSpec
+	// Transient is set by `//injector:transient`.
+	Transient bool
+	// Profiles are set by `//injector:profile <name>...`.
+	Profiles []string
+}
+
+// parseProviderAnnotations interprets annotations attached to a provider function.
+//
+// Supported annotations:
+// - //injector:provide
+// - //injector:ignore
+// - //injector:name <qualifier>
+// - //injector:param <param> <directives>
+// - //injector:group <group> [priority=N] [key=K]
+// - //injector:transient
+// - //injector:profile <profile>...
+func parseProviderAnnotations(anns []annotation) (providerAnnotations, error) {
+	var out providerAnnotations
+
+	for _, a := range anns {
+		switch a.Verb {
+		case "provide":
+			if a.Args != "" {
+				return providerAnnotations{}, errors.New("//injector:provide takes no value")
+			}
+			out.Provide = true
+		case "ignore":
+			if a.Args != "" {
+				return providerAnnotations{}, errors.New("//injector:ignore takes no value")
+			}
+			out.Ignore = true
+		case "name":
+			if a.Args == "" {
+				return providerAnnotations{}, errors.New("//injector:name requires a value")
+			}
+			if out.Qualifier != "" {
+				return providerAnnotations{}, errors.New("//injector:name already set")
+			}
+			out.Qualifier = a.Args
+		case "param":
+			name, raw, _ := strings.Cut(a.Args, " ")
+			if name == "" {
+				return providerAnnotations{}, errors.New("//injector:param requires a parameter name")
+			}
+			tag, err := parseInjectorTag(raw)
+			if err != nil {
+				return providerAnnotations{}, fmt.Errorf("//injector:param %s: %w", name, err)
+			}
+			if tag.Provider != "" || tag.Bind != "" || tag.Arg || tag.Lifecycle || tag.Lazy || tag.Module || tag.Struct || tag.Fields || len(tag.With) > 0 {
+				return providerAnnotations{}, fmt.Errorf("//injector:param %s: only name, group, optional and transient are supported on parameters", name)
+			}
+			if out.Params == nil {
+				out.Params = map[string]InjectTag{}
+			}
+			if _, ok := out.Params[name]; ok {
+				return providerAnnotations{}, fmt.Errorf("//injector:param %s already set", name)
+			}
+			out.Params[name] = tag
+		case "group":
+			g, err := parseGroupAnnotation(a.Args)
+			if err != nil {
+				return providerAnnotations{}, fmt.Errorf("//injector:group: %w", err)
+			}
+			for _, prev := range out.Groups {
+				if prev.Name == g.Name {
+					return providerAnnotations{}, fmt.Errorf("//injector:group %s already set", g.Name)
+				}
+			}
+			out.Groups = append(out.Groups, g)
+		case "transient":
+			if a.Args != "" {
+				return providerAnnotations{}, errors.New("//injector:transient takes no value")
+			}
+			out.Transient = true
+		case "profile":
+			names := strings.Fields(a.Args)
+			if len(names) == 0 {
+				return providerAnnotations{}, errors.New("//injector:profile requires a value")
+			}
+			for _, name := range names {
+				if !token.IsIdentifier(name) {
+					return providerAnnotations{}, fmt.Errorf("//injector:profile %s: must be an identifier", name)
+				}
+				if slices.Contains(out.Profiles, name) {
+					return providerAnnotations{}, fmt.Errorf("//injector:profile %s already set", name)
+				}
+				out.Profiles = append(out.Profiles, name)
+			}
+		default:
+			return providerAnnotations{}, fmt.Errorf("unknown injector annotation %q", a.Verb)
+		}
+	}
+
+	if out.Provide && out.Ignore {
+		return providerAnnotations{}, errors.New("//injector:provide and //injector:ignore are mutually exclusive")
+	}
+
+	return out, nil
+}
+
+// parseGroupAnnotation parses the arguments of `//injector:group <name> [priority=N] [key=K]`.
+func parseGroupAnnotation(args string) (GroupSpec, error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return GroupSpec{}, errors.New("requires a group name")
+	}
+
+	out := GroupSpec{Name: fields[0]}
+	for _, opt := range fields[1:] {
+		key, val, ok := strings.Cut(opt, "=")
+		if !ok || val == "" {
+			return GroupSpec{}, fmt.Errorf("invalid option %q, expected key=value", opt)
+		}
+		switch key {
+		case "priority":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return GroupSpec{}, fmt.Errorf("invalid priority %q", val)
+			}
+			out.Priority = n
+		case "key":
+			out.Key = val
+		default:
+			return GroupSpec{}, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return out, nil
+}
+
+// typeAnnotations is the parsed set of annotations attached to a type declaration.
+type typeAnnotations struct {
+	// Module is set by `//injector:module`.
+	Module bool
+	// Struct is set by `//injector:struct [all]`.
+	Struct bool
+	// StructAll is set by `//injector:struct all`.
+	StructAll bool
+	// Fields is set by `//injector:fields`.
+	Fields bool
+}
+
+// parseTypeAnnotations interprets annotations attached to a type declaration.
+//
+// Supported annotations:
+// - //injector:module
+// - //injector:struct [all]
+// - //injector:fields
+func parseTypeAnnotations(anns []annotation) (typeAnnotations, error) {
+	var out typeAnnotations
+
+	for _, a := range anns {
+		switch a.Verb {
+		case "module":
+			if a.Args != "" {
+				return typeAnnotations{}, errors.New("//injector:module takes no value")
+			}
+			out.Module = true
+		case "struct":
+			if a.Args != "" && a.Args != "all" {
+				return typeAnnotations{}, fmt.Errorf("//injector:struct: unknown option %q", a.Args)
+			}
+			out.Struct = true
+			out.StructAll = a.Args == "all"
+		case "fields":
+			if a.Args != "" {
+				return typeAnnotations{}, errors.New("//injector:fields takes no value")
+			}
+			out.Fields = true
+		default:
+			return typeAnnotations{}, fmt.Errorf("unknown injector annotation %q", a.Verb)
+		}
+	}
+
+	if out.Module && out.Struct {
+		return typeAnnotations{}, errors.New("//injector:module and //injector:struct are mutually exclusive")
+	}
+	if out.Struct && out.Fields {
+		return typeAnnotations{}, errors.New("//injector:struct and //injector:fields are mutually exclusive")
+	}
+
+	return out, nil
+}
+
+// typeDecl is a top-level type spec with its doc comment.
+type typeDecl struct {
+	Spec *ast.TypeSpec
+	Doc  *ast.CommentGroup
+}
+
+// typeDecls returns the top-level type specs of a file in source order.
+// A declaration with a single spec documents that spec (`// Doc` above `type T struct{}`).
+func typeDecls(file *ast.File) []typeDecl {
+	var out []typeDecl
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			doc := ts.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			out = append(out, typeDecl{Spec: ts, Doc: doc})
+		}
+	}
+	return out
+}
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/build/constraint"
 	"go/token"
 	"go/types"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -20,6 +22,11 @@ type ContainerSpec struct {
 	Name     string
 	Position string
 	Fields   []ContainerField
+	// Type is the container struct type, or nil if type information is missing.
+	Type types.Type
+	// BuildConstraint is the `//go:build` line selecting the file declaring the container, if any.
+	// It includes the GOOS and GOARCH suffixes of the file name (e.g. container_linux.go).
+	BuildConstraint string
 }
 
 // ContainerField represents a field within a container struct.
@@ -59,7 +66,55 @@ func CollectContainers(pkgs []*packages.Package) ([]ContainerSpec, error) {
 	if len(errs) > 0 {
 		return nil, fmt.Errorf("scan: %s", joinLines(errs))
 	}
-	return out, nil
+	return withoutProviderTypes(out), nil
+}
+
+// withoutProviderTypes drops the containers that other containers use as struct or field providers.
+func withoutProviderTypes(cs []ContainerSpec) []ContainerSpec {
+	ts := FieldsTypes(cs)
+	for _, st := range StructTypes(cs) {
+		ts = append(ts, st.Type)
+	}
+	return slices.DeleteFunc(cs, func(c ContainerSpec) bool {
+		return slices.ContainsFunc(ts, func(t types.Type) bool {
+			named := namedOf(t)
+			if named == nil {
+				return false
+			}
+			obj := named.Obj()
+			return obj.Pkg() != nil && obj.Pkg().Path() == c.PkgPath && obj.Name() == c.Name
+		})
+	})
+}
+
+// buildConstraint returns the `//go:build` line that selects the builds including file,
+// combining its `//go:build` line with the GOOS and GOARCH suffixes of its name, or "" if it has neither.
+func buildConstraint(fset *token.FileSet, file *ast.File) string {
+	var line string
+	for _, cg := range file.Comments {
+		if cg.Pos() > file.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if constraint.IsGoBuild(c.Text) && line == "" {
+				line = strings.TrimSpace(c.Text)
+			}
+		}
+	}
+
+	fx := fileNameConstraint(fset.Position(file.Package).Filename)
+	if fx == nil {
+		return line
+	}
+	if line == "" {
+		return "//go:build " + fx.String()
+	}
+	x, err := constraint.Parse(line)
+	if err != nil {
+		// Leave the line to be reported by its users.
+		return line
+	}
+	return "//go:build " + (&constraint.AndExpr{X: x, Y: fx}).String()
 }
 
 func collectContainersInPackage(pkg *packages.Package) ([]ContainerSpec, error) {
@@ -71,6 +126,17 @@ func collectContainersInPackage(pkg *packages.Package) ([]ContainerSpec, error)
 			continue
 		}
 
+		// Struct and field providers may have inject-tagged fields, but they are not containers.
+		providerTypes := map[*ast.TypeSpec]bool{}
+		for _, td := range typeDecls(file) {
+			anns, err := parseTypeAnnotations(parseAnnotations(td.Doc))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: invalid annotation on %s: %v", position(pkg.Fset, td.Spec.Pos()), td.Spec.Name.Name, err))
+				continue
+			}
+			providerTypes[td.Spec] = anns.Struct || anns.Fields
+		}
+
 		for node := range ast.Preorder(file) {
 			ts, ok := node.(*ast.TypeSpec)
 			if !ok {
@@ -81,7 +147,7 @@ func collectContainersInPackage(pkg *packages.Package) ([]ContainerSpec, error)
 			}
 
 			st, ok := ts.Type.(*ast.StructType)
-			if !ok {
+			if !ok || providerTypes[ts] {
 				continue
 			}
 
@@ -96,11 +162,15 @@ func collectContainersInPackage(pkg *packages.Package) ([]ContainerSpec, error)
 			}
 
 			spec := ContainerSpec{
-				PkgPath:  pkg.PkgPath,
-				PkgName:  pkg.Name,
-				Name:     ts.Name.Name,
-				Position: position(pkg.Fset, ts.Pos()),
-				Fields:   fields,
+				PkgPath:         pkg.PkgPath,
+				PkgName:         pkg.Name,
+				Name:            ts.Name.Name,
+				Position:        position(pkg.Fset, ts.Pos()),
+				Fields:          fields,
+				BuildConstraint: buildConstraint(pkg.Fset, file),
+			}
+			if obj, ok := pkg.TypesInfo.Defs[ts.Name].(*types.TypeName); ok {
+				spec.Type = obj.Type()
 			}
 
 			out = append(out, spec)
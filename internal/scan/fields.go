@@ -0,0 +1,136 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// FieldsTypes returns the types of container fields marked with `inject:"fields"`.
+// Their exported fields are providers (see ProviderOptions.Fields).
+func FieldsTypes(cs []ContainerSpec) []types.Type {
+	var out []types.Type
+	for _, c := range cs {
+		for _, f := range c.Fields {
+			if f.Inject.Fields && f.Type != nil {
+				out = append(out, f.Type)
+			}
+		}
+	}
+	return out
+}
+
+// collectFieldProviders returns the field providers of the types in pkg
+// annotated with `//injector:fields`. They select the fields of a value of the type.
+func collectFieldProviders(pkg *packages.Package) ([]ProviderSpec, []SkippedProvider, error) {
+	var out []ProviderSpec
+	var skipped []SkippedProvider
+	var errs []string
+
+	for _, file := range pkg.Syntax {
+		if file == nil {
+			continue
+		}
+		for _, td := range typeDecls(file) {
+			ts := td.Spec
+			anns, err := parseTypeAnnotations(parseAnnotations(td.Doc))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: invalid annotation on %s: %v", position(pkg.Fset, ts.Pos()), ts.Name.Name, err))
+				continue
+			}
+			if !anns.Fields {
+				continue
+			}
+			obj, ok := pkg.TypesInfo.Defs[ts.Name].(*types.TypeName)
+			if !ok {
+				errs = append(errs, fmt.Sprintf("%s: type information is missing for %s", position(pkg.Fset, ts.Pos()), ts.Name.Name))
+				continue
+			}
+			specs, skips, err := fieldProviders(pkg.Fset, obj.Type())
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", position(pkg.Fset, ts.Pos()), err))
+				continue
+			}
+			out = append(out, specs...)
+			skipped = append(skipped, skips...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errors.New(joinLines(errs))
+	}
+	return out, skipped, nil
+}
+
+// fieldProviders returns a provider for each exported field of t, a struct type or a pointer to one.
+// Each provider depends on a value of t and selects its field.
+// A field tag may set the binding name with `inject:"name:<qualifier>"`.
+//
+// Fields of a type that cannot be a provider result (e.g. `Port int`) are skipped,
+// since they would satisfy any dependency of their type; tagging one is an error.
+func fieldProviders(fset *token.FileSet, t types.Type) ([]ProviderSpec, []SkippedProvider, error) {
+	named := namedOf(t)
+	if named == nil {
+		return nil, nil, fmt.Errorf("fields of %s: must be a named type or a pointer to a named type", t)
+	}
+	obj := named.Obj()
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil, fmt.Errorf("fields of %s: not a struct", obj.Name())
+	}
+	if named.TypeParams().Len() > 0 {
+		return nil, nil, fmt.Errorf("fields of %s: generic types are not supported", obj.Name())
+	}
+
+	var out []ProviderSpec
+	var skipped []SkippedProvider
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Exported() {
+			continue
+		}
+
+		var tag InjectTag
+		raw, tagged := reflect.StructTag(st.Tag(i)).Lookup("inject")
+		if tagged {
+			var err error
+			tag, err = parseInjectorTag(raw)
+			if err != nil {
+				return nil, nil, fmt.Errorf("fields of %s: field %s: %w", obj.Name(), f.Name(), err)
+			}
+			if !reflect.DeepEqual(tag, InjectTag{Name: tag.Name}) {
+				return nil, nil, fmt.Errorf("fields of %s: field %s: only name is supported", obj.Name(), f.Name())
+			}
+		}
+
+		if why := unsupportedResultType(f.Type()); why != "" {
+			if tagged {
+				return nil, nil, fmt.Errorf("fields of %s: field %s: its type %s is not supported: %s", obj.Name(), f.Name(), typeStringByName(f.Type()), why)
+			}
+			skipped = append(skipped, SkippedProvider{
+				PkgPath:  obj.Pkg().Path(),
+				Name:     obj.Name() + "." + f.Name(),
+				Reason:   fmt.Sprintf("field type %s is not supported: %s", typeStringByName(f.Type()), why),
+				Position: position(fset, f.Pos()),
+			})
+			continue
+		}
+
+		out = append(out, ProviderSpec{
+			PkgPath:      obj.Pkg().Path(),
+			PkgName:      obj.Pkg().Name(),
+			Name:         obj.Name() + "." + f.Name(),
+			ResultType:   f.Type(),
+			ResultString: typeStringByName(f.Type()),
+			Params:       []types.Type{t},
+			Qualifier:    tag.Name,
+			FieldOf:      t,
+			Position:     position(fset, f.Pos()),
+		})
+	}
+	return out, skipped, nil
+}
@@ -0,0 +1,105 @@
+package scan
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCollectProvidersFields(t *testing.T) {
+	const src = `package app
+
+type DSN string
+type Logger struct{}
+
+//injector:fields
+type Config struct {
+	DSN     DSN
+	Replica DSN ` + "`inject:\"name:Replica\"`" + `
+	Logger  *Logger
+	Port    int
+	Tags    struct{ Env string }
+	secret  string
+}
+
+type Settings struct {
+	Timeout DSN
+	Debug   bool
+}
+`
+
+	tests := []struct {
+		name        string
+		extra       string
+		container   string
+		want        []string
+		wantNames   map[string]string
+		wantSkipped []string
+		wantErr     string
+	}{
+		{
+			name:        "exported fields of a named type",
+			want:        []string{"Config.DSN", "Config.Replica", "Config.Logger"},
+			wantNames:   map[string]string{"Config.Replica": "Replica"},
+			wantSkipped: []string{"Config.Port", "Config.Tags"},
+		},
+		{
+			name:        "container field marked with fields",
+			container:   "Settings Settings `inject:\"fields\"`",
+			want:        []string{"Config.DSN", "Config.Replica", "Config.Logger", "Settings.Timeout"},
+			wantNames:   map[string]string{"Config.Replica": "Replica"},
+			wantSkipped: []string{"Config.Port", "Config.Tags", "Settings.Debug"},
+		},
+		{
+			name:    "tagged field of a predeclared type",
+			extra:   "\n//injector:fields\ntype Env struct {\n\tName string `inject:\"\"`\n}\n",
+			wantErr: "fields of Env: field Name: its type string is not supported",
+		},
+		{
+			name:    "field with an unsupported directive",
+			extra:   "\n//injector:fields\ntype Env struct {\n\tDSN DSN `inject:\"optional\"`\n}\n",
+			wantErr: "fields of Env: field DSN: only name is supported",
+		},
+		{
+			name:    "annotated type that is not a struct",
+			extra:   "\n//injector:fields\ntype Names []string\n",
+			wantErr: "fields of Names: not a struct",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := src + tt.extra
+			if tt.container != "" {
+				code += "\ntype Container struct {\n\t" + tt.container + "\n}\n"
+			}
+			pkgs := loadPackages(t, testPackage{path: "example.com/app", files: map[string]string{"app.go": code}})
+
+			var ps []ProviderSpec
+			var skipped []SkippedProvider
+			containers, err := CollectContainers(pkgs)
+			if err == nil {
+				ps, skipped, err = CollectProviders(pkgs, ProviderOptions{Fields: FieldsTypes(containers)})
+			}
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+
+			var fields []string
+			for _, p := range ps {
+				if p.FieldOf == nil {
+					continue
+				}
+				fields = append(fields, p.Name)
+				if got := p.Qualifier; got != tt.wantNames[p.Name] {
+					t.Errorf("%s: name = %q, want %q", p.Name, got, tt.wantNames[p.Name])
+				}
+			}
+			if !slices.Equal(fields, tt.want) {
+				t.Errorf("field providers = %v, want %v", fields, tt.want)
+			}
+			if got := skippedNames(skipped); !slices.Equal(got, tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", got, tt.wantSkipped)
+			}
+		})
+	}
+}
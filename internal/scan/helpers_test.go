@@ -0,0 +1,114 @@
+package scan
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// testPackage is the source of a package: its path and its files keyed by name.
+type testPackage struct {
+	path  string
+	files map[string]string
+}
+
+// loadPackages type-checks pkgs in order, as go/packages would load them.
+// A package may import the packages before it.
+func loadPackages(t *testing.T, pkgs ...testPackage) []*packages.Package {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	std := importer.ForCompiler(fset, "source", nil)
+	loaded := map[string]*types.Package{}
+	imp := importerFunc(func(path string) (*types.Package, error) {
+		if p, ok := loaded[path]; ok {
+			return p, nil
+		}
+		return std.Import(path)
+	})
+
+	var out []*packages.Package
+	for _, tp := range pkgs {
+		names := make([]string, 0, len(tp.files))
+		for name := range tp.files {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		var files []*ast.File
+		for _, name := range names {
+			f, err := parser.ParseFile(fset, "/src/"+tp.path+"/"+name, tp.files[name], parser.ParseComments)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			files = append(files, f)
+		}
+
+		info := &types.Info{
+			Types: map[ast.Expr]types.TypeAndValue{},
+			Defs:  map[*ast.Ident]types.Object{},
+			Uses:  map[*ast.Ident]types.Object{},
+		}
+		conf := types.Config{Importer: imp}
+		tpkg, err := conf.Check(tp.path, fset, files, info)
+		if err != nil {
+			t.Fatalf("check %s: %v", tp.path, err)
+		}
+		loaded[tp.path] = tpkg
+
+		out = append(out, &packages.Package{
+			ID:        tp.path,
+			Name:      tpkg.Name(),
+			PkgPath:   tp.path,
+			Fset:      fset,
+			Syntax:    files,
+			Types:     tpkg,
+			TypesInfo: info,
+		})
+	}
+	return out
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// providerNames returns the names of ps.
+func providerNames(ps []ProviderSpec) []string {
+	out := make([]string, 0, len(ps))
+	for _, p := range ps {
+		out = append(out, p.Name)
+	}
+	return out
+}
+
+// skippedNames returns the names of ss.
+func skippedNames(ss []SkippedProvider) []string {
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		out = append(out, s.Name)
+	}
+	return out
+}
+
+// checkError reports whether err matches want: nil for "", or an error containing want.
+func checkError(t *testing.T, err error, want string) bool {
+	t.Helper()
+
+	switch {
+	case want == "" && err != nil:
+		t.Fatalf("unexpected error: %v", err)
+	case want != "" && err == nil:
+		t.Fatalf("error = nil, want %q", want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Fatalf("error = %v, want %q", err, want)
+	}
+	return err == nil
+}
@@ -0,0 +1,93 @@
+package scan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ModuleTypes returns the types of container fields marked with `inject:"module"`.
+// Their exported methods are collected as providers (see ProviderOptions.Modules).
+func ModuleTypes(cs []ContainerSpec) []types.Type {
+	var out []types.Type
+	for _, c := range cs {
+		for _, f := range c.Fields {
+			if f.Inject.Module && f.Type != nil {
+				out = append(out, f.Type)
+			}
+		}
+	}
+	return out
+}
+
+// collectModules returns the module types declared in pkg, keyed by type name.
+// The value is the type of the module dependency: the field type for modules
+// marked by a container field, and a pointer to the type for `//injector:module` annotations.
+func collectModules(pkg *packages.Package, fieldModules []types.Type) (map[*types.TypeName]types.Type, error) {
+	out := map[*types.TypeName]types.Type{}
+
+	for _, file := range pkg.Syntax {
+		if file == nil {
+			continue
+		}
+		for _, td := range typeDecls(file) {
+			ts := td.Spec
+			anns, err := parseTypeAnnotations(parseAnnotations(td.Doc))
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid annotation on %s: %v", position(pkg.Fset, ts.Pos()), ts.Name.Name, err)
+			}
+			if !anns.Module {
+				continue
+			}
+			obj, ok := pkg.TypesInfo.Defs[ts.Name].(*types.TypeName)
+			if !ok {
+				return nil, fmt.Errorf("%s: type information is missing for %s", position(pkg.Fset, ts.Pos()), ts.Name.Name)
+			}
+			if ts.TypeParams != nil {
+				return nil, fmt.Errorf("%s: generic type %s cannot be a module", position(pkg.Fset, ts.Pos()), ts.Name.Name)
+			}
+			out[obj] = types.NewPointer(obj.Type())
+		}
+	}
+
+	for _, t := range fieldModules {
+		named := namedOf(t)
+		if named == nil {
+			return nil, fmt.Errorf("module %s must be a named type or a pointer to a named type", t)
+		}
+		if obj := named.Obj(); obj.Pkg() != nil && obj.Pkg().Path() == pkg.PkgPath {
+			out[obj] = t
+		}
+	}
+
+	return out, nil
+}
+
+// receiverModule returns the module dependency type of a method declaration,
+// or nil if its receiver is not a module.
+func receiverModule(pkg *packages.Package, fd *ast.FuncDecl, modules map[*types.TypeName]types.Type) types.Type {
+	obj, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func)
+	if !ok {
+		return nil
+	}
+	recv := obj.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return nil
+	}
+	named := namedOf(recv.Type())
+	if named == nil {
+		return nil
+	}
+	return modules[named.Obj()]
+}
+
+// namedOf returns the named type of T or *T.
+func namedOf(t types.Type) *types.Named {
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := types.Unalias(t).(*types.Named)
+	return named
+}
@@ -0,0 +1,89 @@
+package scan
+
+import (
+	"go/types"
+	"slices"
+	"testing"
+)
+
+func TestCollectProvidersModules(t *testing.T) {
+	const src = `package app
+
+type DB struct{}
+type Cache struct{}
+type Config struct{}
+
+//injector:module
+type DBModule struct{}
+
+func (m *DBModule) NewDB(*Config) *DB { return &DB{} }
+func (m *DBModule) newCache() *Cache { return &Cache{} }
+
+type CacheModule struct{}
+
+func (CacheModule) NewCache() *Cache { return &Cache{} }
+
+type Helper struct{}
+
+func (Helper) NewConfig() *Config { return &Config{} }
+
+type Container struct {
+	Cache CacheModule ` + "`inject:\"module\"`" + `
+}
+`
+
+	tests := []struct {
+		name       string
+		extra      string
+		strict     bool
+		want       []string
+		wantParams map[string]int
+		wantErr    string
+	}{
+		{
+			name:       "exported methods of annotated and field modules",
+			want:       []string{"DBModule.NewDB", "CacheModule.NewCache"},
+			wantParams: map[string]int{"DBModule.NewDB": 2, "CacheModule.NewCache": 1},
+		},
+		{
+			name:   "module methods in strict mode",
+			strict: true,
+			want:   []string{"DBModule.NewDB", "CacheModule.NewCache"},
+		},
+		{
+			name:    "generic module",
+			extra:   "\n//injector:module\ntype Box[T any] struct{}\n",
+			wantErr: "generic type Box cannot be a module",
+		},
+		{
+			name:    "module annotation with a value",
+			extra:   "\n//injector:module db\ntype Other struct{}\n",
+			wantErr: "//injector:module takes no value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgs := loadPackages(t, testPackage{path: "example.com/app", files: map[string]string{"app.go": src + tt.extra}})
+			var ps []ProviderSpec
+			containers, err := CollectContainers(pkgs)
+			if err == nil {
+				ps, _, err = CollectProviders(pkgs, ProviderOptions{Strict: tt.strict, Modules: ModuleTypes(containers)})
+			}
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := providerNames(ps); !slices.Equal(got, tt.want) {
+				t.Errorf("providers = %v, want %v", got, tt.want)
+			}
+			for _, p := range ps {
+				if p.Module == nil || !types.Identical(p.Params[0], p.Module) {
+					t.Errorf("%s: first param = %v, want module %v", p.Name, p.Params, p.Module)
+				}
+				if want, ok := tt.wantParams[p.Name]; ok && len(p.Params) != want {
+					t.Errorf("%s: %d params, want %d", p.Name, len(p.Params), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package scan
+
+import (
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+)
+
+// knownOS and knownArch are the GOOS and GOARCH values the go command
+// recognizes in file name suffixes (see go/build).
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true,
+		"nacl": true, "netbsd": true, "openbsd": true, "plan9": true, "solaris": true,
+		"wasip1": true, "windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true,
+		"arm64": true, "arm64be": true, "loong64": true, "mips": true, "mipsle": true,
+		"mips64": true, "mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true,
+		"ppc64": true, "ppc64le": true, "riscv": true, "riscv64": true, "s390": true,
+		"s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+	}
+)
+
+// fileNameConstraint returns the build constraint implied by the GOOS and GOARCH suffixes
+// of the file name (e.g. foo_linux.go, foo_linux_amd64_test.go), or nil if it has none.
+// It follows the rules of the go command: the part before the first "_" is ignored.
+func fileNameConstraint(filename string) constraint.Expr {
+	name, _, _ := strings.Cut(filepath.Base(filename), ".")
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return nil
+	}
+	l := strings.Split(name[i:], "_")
+	if n := len(l); n > 0 && l[n-1] == "test" {
+		l = l[:n-1]
+	}
+	n := len(l)
+	if n >= 2 && knownOS[l[n-2]] && knownArch[l[n-1]] {
+		return &constraint.AndExpr{X: &constraint.TagExpr{Tag: l[n-2]}, Y: &constraint.TagExpr{Tag: l[n-1]}}
+	}
+	if n >= 1 && (knownOS[l[n-1]] || knownArch[l[n-1]]) {
+		return &constraint.TagExpr{Tag: l[n-1]}
+	}
+	return nil
+}
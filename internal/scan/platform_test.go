@@ -0,0 +1,94 @@
+package scan
+
+import "testing"
+
+func TestFileNameConstraint(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{filename: "container.go", want: ""},
+		{filename: "/src/app/container_linux.go", want: "linux"},
+		{filename: "container_amd64.go", want: "amd64"},
+		{filename: "container_linux_amd64.go", want: "linux && amd64"},
+		{filename: "container_windows_test.go", want: "windows"},
+		{filename: "linux.go", want: ""},
+		{filename: "container_unix.go", want: ""},
+		{filename: "container_test.go", want: ""},
+		{filename: "injector_gen.linux_amd64.go", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			var got string
+			if x := fileNameConstraint(tt.filename); x != nil {
+				got = x.String()
+			}
+			if got != tt.want {
+				t.Errorf("fileNameConstraint(%q) = %q, want %q", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectContainersBuildConstraint(t *testing.T) {
+	pkgs := loadPackages(t, testPackage{
+		path: "example.com/app",
+		files: map[string]string{
+			"app.go": `package app
+
+type DB struct{}
+
+func NewDB() *DB { return &DB{} }
+`,
+			"plain.go": `package app
+
+type Plain struct {
+	DB *DB ` + "`inject:\"\"`" + `
+}
+`,
+			"tagged.go": `//go:build integration || e2e
+
+package app
+
+type Tagged struct {
+	DB *DB ` + "`inject:\"\"`" + `
+}
+`,
+			"linux_container_linux.go": `package app
+
+type Linux struct {
+	DB *DB ` + "`inject:\"\"`" + `
+}
+`,
+			"both_linux_amd64.go": `//go:build integration || e2e
+
+package app
+
+type Both struct {
+	DB *DB ` + "`inject:\"\"`" + `
+}
+`,
+		},
+	})
+
+	containers, err := CollectContainers(pkgs)
+	if err != nil {
+		t.Fatalf("CollectContainers: %v", err)
+	}
+	got := map[string]string{}
+	for _, c := range containers {
+		got[c.Name] = c.BuildConstraint
+	}
+	want := map[string]string{
+		"Plain":  "",
+		"Tagged": "//go:build integration || e2e",
+		"Linux":  "//go:build linux",
+		"Both":   "//go:build (integration || e2e) && linux && amd64",
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s: BuildConstraint = %q, want %q", name, got[name], w)
+		}
+	}
+}
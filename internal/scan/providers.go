@@ -4,7 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
+	"path"
+	"slices"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
@@ -16,50 +20,191 @@ type ProviderSpec struct {
 	Name         string
 	ResultType   types.Type
 	ResultString string
-	ReturnError  bool
-	Params       []types.Type
-	Position     string
+	// ReturnCleanup reports whether the function returns a cleanup func() after its value.
+	ReturnCleanup bool
+	ReturnError   bool
+	Params        []types.Type
+	// Qualifier is the binding name set by `//injector:name <qualifier>`.
+	Qualifier string
+	// ParamTags holds directives for each parameter, aligned with Params.
+	// Parameters without `//injector:param` have a zero InjectTag.
+	ParamTags []InjectTag
+	// Groups lists the groups the provider contributes its value to.
+	Groups []GroupSpec
+	// Module is the type of the module value for a method provider, or nil for a function.
+	// It is also the first element of Params, and Name is qualified by the module type name.
+	Module types.Type
+	// Generic is the signature of a generic function, or nil.
+	// ResultType and Params then refer to its type parameters.
+	Generic *types.Signature
+	// Struct reports whether the value is a struct literal rather than a function call.
+	// Fields then names the struct field assigned from each parameter.
+	Struct bool
+	Fields []string
+	// FieldOf is the struct type of a field provider, or nil.
+	// It is also the only element of Params, and Name is qualified by the struct type name.
+	FieldOf types.Type
+	// Var reports whether the provider is a package-level variable.
+	// Value then reports whether the variable is the value itself rather than a function to call.
+	Var   bool
+	Value bool
+	// Transient reports whether every dependent gets a new value, set by `//injector:transient`.
+	Transient bool
+	// Profiles are the profiles the provider belongs to, set by `//injector:profile`.
+	Profiles []string
+	// Test reports whether the provider is declared in a _test.go file.
+	Test     bool
+	Position string
+}
+
+// GroupSpec is a group membership declared by `//injector:group <name> [priority=N] [key=K]`.
+type GroupSpec struct {
+	Name string
+	// Priority orders the members of a group; higher priorities come first.
+	Priority int
+	// Key is the entry key of the member when the group is collected into a map.
+	Key string
+}
+
+// ProviderOptions configures provider discovery.
+type ProviderOptions struct {
+	// Strict restricts discovery to functions annotated with `//injector:provide`
+	// and to packages annotated with `//injector:providers`.
+	Strict bool
+
+	// Exclude lists package path patterns whose functions are never collected.
+	// Patterns use path.Match syntax; a trailing "/..." also matches subpackages.
+	Exclude []string
+
+	// Modules lists types whose exported methods are providers,
+	// in addition to types annotated with `//injector:module`.
+	Modules []types.Type
+
+	// Structs lists struct types built from their fields,
+	// in addition to types annotated with `//injector:struct`.
+	Structs []StructType
+
+	// Fields lists struct types whose exported fields are providers,
+	// in addition to types annotated with `//injector:fields`.
+	Fields []types.Type
+}
+
+// SkippedProvider represents a function that has a provider shape
+// but was not collected, along with the reason.
+type SkippedProvider struct {
+	PkgPath  string
+	Name     string
+	Reason   string
+	Position string
 }
 
 // CollectProviders scans loaded packages and collects provider functions.
 //
 // Rule:
-// - Top-level functions only (func Foo(...))
-// - Exactly 1 result
+// - Top-level functions (func Foo(...)) and exported methods of modules
+// - Struct types annotated with `//injector:struct` or listed in Structs, built from their fields
+// - Exported fields of struct types annotated with `//injector:fields` or listed in Fields
+// - Package-level variables annotated with `//injector:provide`; function variables are called
+// - Results are (T), (T, error), (T, func()), or (T, func(), error)
 // - Result type can be any named type, pointer to named type, or interface type
 // - Parameters are recorded as dependency requirements
-func CollectProviders(pkgs []*packages.Package) ([]ProviderSpec, error) {
+// - `//injector:name` and `//injector:param` annotations qualify the result and parameters
+// - `//injector:profile` restricts a provider to the profiles it names
+// - In strict mode, only annotated functions (or functions in annotated packages) are collected
+// - Functions annotated with `//injector:ignore` and packages matching Exclude are never collected
+// - Providers declared in _test.go files are marked as Test
+func CollectProviders(pkgs []*packages.Package, opts ProviderOptions) ([]ProviderSpec, []SkippedProvider, error) {
 	if len(pkgs) == 0 {
-		return nil, errors.New("scan: no packages")
+		return nil, nil, errors.New("scan: no packages")
+	}
+	for _, pattern := range opts.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, nil, fmt.Errorf("scan: invalid exclude pattern %q: %w", pattern, err)
+		}
 	}
 
 	var out []ProviderSpec
+	var skipped []SkippedProvider
 	var errs []string
 
 	for _, pkg := range pkgs {
 		if pkg == nil {
 			continue
 		}
-		decls, err := collectProvidersInPackage(pkg)
+		decls, skips, err := collectProvidersInPackage(pkg, opts)
 		if err != nil {
 			errs = append(errs, err.Error())
 			continue
 		}
 		out = append(out, decls...)
+		skipped = append(skipped, skips...)
+	}
+
+	var fset *token.FileSet
+	for _, pkg := range pkgs {
+		if pkg != nil {
+			fset = pkg.Fset
+			break
+		}
+	}
+	for _, st := range opts.Structs {
+		if slices.ContainsFunc(out, func(p ProviderSpec) bool {
+			return p.Struct && types.Identical(p.ResultType, st.Type)
+		}) {
+			continue
+		}
+		spec, err := structProvider(fset, st.Type, st.All)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		out = append(out, spec)
+	}
+	for _, t := range opts.Fields {
+		if slices.ContainsFunc(out, func(p ProviderSpec) bool {
+			return p.FieldOf != nil && types.Identical(p.FieldOf, t)
+		}) {
+			continue
+		}
+		specs, skips, err := fieldProviders(fset, t)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		out = append(out, specs...)
+		skipped = append(skipped, skips...)
+	}
+
+	for i := range out {
+		out[i].Test = isTestPosition(out[i].Position)
 	}
 
 	if len(errs) > 0 {
-		return nil, fmt.Errorf("scan: %s", joinLines(errs))
+		return nil, nil, fmt.Errorf("scan: %s", joinLines(errs))
 	}
-	return out, nil
+	return out, skipped, nil
 }
 
-func collectProvidersInPackage(pkg *packages.Package) ([]ProviderSpec, error) {
+func collectProvidersInPackage(pkg *packages.Package, opts ProviderOptions) ([]ProviderSpec, []SkippedProvider, error) {
 	var out []ProviderSpec
+	var skipped []SkippedProvider
 	var errs []string
 
+	pkgProviders, err := isProvidersPackage(pkg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	excludedBy := matchExclude(opts.Exclude, pkg.PkgPath)
+
+	modules, err := collectModules(pkg, opts.Modules)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	for _, file := range pkg.Syntax {
-		if file == nil {
+		if file == nil || isInjectorGenerated(file) {
+			// Generated constructors are provided by their containers (see resolve.ContainerProviders).
 			continue
 		}
 
@@ -68,91 +213,255 @@ func collectProvidersInPackage(pkg *packages.Package) ([]ProviderSpec, error) {
 			if !ok || fd == nil {
 				continue
 			}
-			if fd.Recv != nil {
-				// Methods are not providers.
-				continue
-			}
 			if fd.Name == nil || fd.Name.Name == "" {
 				continue
 			}
-			if fd.Type == nil || fd.Type.Results == nil {
-				continue
+			var module types.Type
+			if fd.Recv != nil {
+				// Only exported methods of modules are providers.
+				module = receiverModule(pkg, fd, modules)
+				if module == nil || !fd.Name.IsExported() {
+					continue
+				}
 			}
-			if rl := len(fd.Type.Results.List); rl != 1 && rl != 2 {
-				// Require exactly 1 or 2 result(s).
-				continue
+			name := fd.Name.Name
+			if module != nil {
+				name = namedOf(module).Obj().Name() + "." + name
 			}
 
-			firstRes := fd.Type.Results.List[0]
-			if firstRes == nil || firstRes.Type == nil {
+			anns, err := parseProviderAnnotations(parseAnnotations(fd.Doc))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: invalid annotation on %s: %v", position(pkg.Fset, fd.Pos()), name, err))
 				continue
 			}
 
-			if pkg.TypesInfo == nil {
+			resType, sig, returnCleanup, returnError, reason := providerSignature(pkg, fd)
+			if reason != "" {
+				if anns.Provide {
+					errs = append(errs, fmt.Sprintf("%s: %s is annotated with //injector:provide but %s", position(pkg.Fset, fd.Pos()), name, reason))
+				}
 				continue
 			}
 
-			resType := pkg.TypesInfo.TypeOf(firstRes.Type)
-			if resType == nil {
-				continue
+			var skipReason string
+			switch {
+			case anns.Ignore:
+				skipReason = "annotated with //injector:ignore"
+			case excludedBy != "":
+				skipReason = fmt.Sprintf("package excluded by %q", excludedBy)
+			case opts.Strict && !anns.Provide && !pkgProviders && module == nil:
+				skipReason = "not annotated with //injector:provide (strict mode)"
 			}
-
-			if isBuiltinError(resType) {
-				// func Foo() error is not a provider.
+			if skipReason != "" {
+				skipped = append(skipped, SkippedProvider{
+					PkgPath:  pkg.PkgPath,
+					Name:     name,
+					Reason:   skipReason,
+					Position: position(pkg.Fset, fd.Pos()),
+				})
 				continue
 			}
 
-			if !isProviderResultType(resType) {
-				// Skip unsupported result shapes.
+			params := extractParamTypes(sig)
+			paramTags, err := extractParamTags(sig, anns.Params)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: invalid annotation on %s: %v", position(pkg.Fset, fd.Pos()), name, err))
 				continue
 			}
-
-			sig, _ := pkg.TypesInfo.Defs[fd.Name].Type().(*types.Signature)
-			if sig == nil {
-				// Fallback: try to obtain signature from types info on FuncDecl.
-				if obj, ok := pkg.TypesInfo.Defs[fd.Name]; ok && obj != nil {
-					sig, _ = obj.Type().(*types.Signature)
+			if module != nil {
+				// The module value is the first dependency of a method provider.
+				params = append([]types.Type{module}, params...)
+				if paramTags != nil {
+					paramTags = append([]InjectTag{{}}, paramTags...)
 				}
 			}
-			if sig == nil {
-				continue
+
+			out = append(out, ProviderSpec{
+				PkgPath:       pkg.PkgPath,
+				PkgName:       pkg.Name,
+				Name:          name,
+				ResultType:    resType,
+				ResultString:  typeStringByName(resType),
+				ReturnCleanup: returnCleanup,
+				ReturnError:   returnError,
+				Params:        params,
+				Qualifier:     anns.Qualifier,
+				ParamTags:     paramTags,
+				Groups:        anns.Groups,
+				Transient:     anns.Transient,
+				Profiles:      anns.Profiles,
+				Module:        module,
+				Generic:       genericSignature(sig),
+				Position:      position(pkg.Fset, fd.Pos()),
+			})
+		}
+	}
+
+	structs, err := collectStructProviders(pkg)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	fields, fieldSkips, err := collectFieldProviders(pkg)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	skipped = append(skipped, fieldSkips...)
+	vars, varSkips, err := collectVarProviders(pkg, excludedBy)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	out = append(out, vars...)
+	skipped = append(skipped, varSkips...)
+	for _, spec := range slices.Concat(structs, fields) {
+		if excludedBy != "" {
+			skipped = append(skipped, SkippedProvider{
+				PkgPath:  spec.PkgPath,
+				Name:     spec.Name,
+				Reason:   fmt.Sprintf("package excluded by %q", excludedBy),
+				Position: spec.Position,
+			})
+			continue
+		}
+		out = append(out, spec)
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.New(joinLines(errs))
+	}
+	return out, skipped, nil
+}
+
+// providerSignature inspects a function declaration and reports whether it has a provider shape.
+// A non-empty reason explains why the function cannot be a provider.
+func providerSignature(pkg *packages.Package, fd *ast.FuncDecl) (resType types.Type, sig *types.Signature, returnCleanup bool, returnError bool, reason string) {
+	if pkg.TypesInfo == nil {
+		return nil, nil, false, false, "type information is missing"
+	}
+	if obj, ok := pkg.TypesInfo.Defs[fd.Name]; ok && obj != nil {
+		sig, _ = obj.Type().(*types.Signature)
+	}
+	if sig == nil {
+		return nil, nil, false, false, "type information is missing"
+	}
+
+	resType, returnCleanup, returnError, reason = providerResults(sig)
+	if reason != "" {
+		return nil, nil, false, false, reason
+	}
+	return resType, sig, returnCleanup, returnError, ""
+}
+
+// providerResults reports whether the results of sig have a provider shape.
+// A non-empty reason explains why they do not.
+func providerResults(sig *types.Signature) (resType types.Type, returnCleanup bool, returnError bool, reason string) {
+	results := sig.Results()
+	if results.Len() == 0 {
+		return nil, false, false, "it has no results"
+	}
+
+	resType = results.At(0).Type()
+	if isBuiltinError(resType) {
+		// func Foo() error is not a provider.
+		return nil, false, false, "it returns only an error"
+	}
+
+	if why := unsupportedResultType(resType); why != "" {
+		// Skip unsupported result shapes.
+		return nil, false, false, fmt.Sprintf("its result %s is not supported: %s", typeStringByName(resType), why)
+	}
+
+	// Accepted shapes: (T), (T, error), (T, func()), (T, func(), error).
+	rest := make([]types.Type, 0, results.Len()-1)
+	for i := 1; i < results.Len(); i++ {
+		rest = append(rest, results.At(i).Type())
+	}
+	if len(rest) > 0 && isCleanupFunc(rest[0]) {
+		returnCleanup = true
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && isBuiltinError(rest[0]) {
+		returnError = true
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		return nil, false, false, "it must return (T), (T, error), (T, func()), or (T, func(), error)"
+	}
+
+	return resType, returnCleanup, returnError, ""
+}
+
+// generatedComment is the first line of the files written by the generator.
+const generatedComment = "// Code generated by injector. DO NOT EDIT."
+
+// isInjectorGenerated reports whether file was written by the generator.
+func isInjectorGenerated(file *ast.File) bool {
+	for _, cg := range file.Comments {
+		if cg.Pos() > file.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if c.Text == generatedComment {
+				return true
 			}
+		}
+	}
+	return false
+}
 
-			var returnError bool
-			if len(fd.Type.Results.List) == 2 {
-				secondRes := fd.Type.Results.List[1]
-				if secondRes != nil && secondRes.Type != nil {
-					secondResType := pkg.TypesInfo.TypeOf(secondRes.Type)
-					if secondResType != nil && isBuiltinError(secondResType) {
-						returnError = true
-					}
+// isProvidersPackage reports whether any file in the package is annotated with `//injector:providers`.
+func isProvidersPackage(pkg *packages.Package) (bool, error) {
+	var found bool
+	for _, file := range pkg.Syntax {
+		if file == nil {
+			continue
+		}
+		for _, a := range parseAnnotations(file.Doc) {
+			switch a.Verb {
+			case "providers":
+				if a.Args != "" {
+					return false, fmt.Errorf("%s: //injector:providers takes no value", position(pkg.Fset, file.Package))
 				}
+				found = true
+			default:
+				return false, fmt.Errorf("%s: unknown package annotation %q", position(pkg.Fset, file.Package), a.Verb)
 			}
+		}
+	}
+	return found, nil
+}
 
-			params := extractParamTypes(sig)
-
-			out = append(out, ProviderSpec{
-				PkgPath:    pkg.PkgPath,
-				PkgName:    pkg.Name,
-				Name:       fd.Name.Name,
-				ResultType: resType,
-				ResultString: types.TypeString(resType, func(p *types.Package) string {
-					if p == nil {
-						return ""
-					}
-					return p.Name()
-				}),
-				ReturnError: returnError,
-				Params:      params,
-				Position:    position(pkg.Fset, fd.Pos()),
-			})
+// matchExclude returns the first pattern that matches pkgPath, or "" if none does.
+// Patterns are validated by CollectProviders.
+func matchExclude(patterns []string, pkgPath string) string {
+	for _, pattern := range patterns {
+		if base, ok := strings.CutSuffix(pattern, "/..."); ok {
+			if pkgPath == base || strings.HasPrefix(pkgPath, base+"/") {
+				return pattern
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, pkgPath); ok {
+			return pattern
 		}
 	}
+	return ""
+}
 
-	if len(errs) > 0 {
-		return nil, errors.New(joinLines(errs))
+// typeStringByName formats t with types qualified by package name.
+func typeStringByName(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == nil {
+			return ""
+		}
+		return p.Name()
+	})
+}
+
+// genericSignature returns sig if it declares type parameters, or nil.
+func genericSignature(sig *types.Signature) *types.Signature {
+	if sig.TypeParams().Len() == 0 {
+		return nil
 	}
-	return out, nil
+	return sig
 }
 
 func extractParamTypes(sig *types.Signature) []types.Type {
@@ -175,41 +484,58 @@ func extractParamTypes(sig *types.Signature) []types.Type {
 	return out
 }
 
-func isProviderResultType(t types.Type) bool {
-	// Allowed:
-	// - named types: T
-	// - pointers to named types: *T
-	// - interface types (including named interfaces): interface{...} or type I interface{...}
-	if isNamedOrPtrToNamed(t) {
-		return true
-	}
-	return isInterfaceType(t)
-}
+// extractParamTags aligns `//injector:param` directives with the signature parameters.
+func extractParamTags(sig *types.Signature, byName map[string]InjectTag) ([]InjectTag, error) {
+	tup := sig.Params()
+	out := make([]InjectTag, tup.Len())
 
-func isInterfaceType(t types.Type) bool {
-	switch tt := t.(type) {
-	case *types.Interface:
-		return true
-	case *types.Named:
-		_, ok := tt.Underlying().(*types.Interface)
-		return ok
-	default:
-		return false
+	used := make(map[string]struct{}, len(byName))
+	for i := 0; i < tup.Len(); i++ {
+		name := tup.At(i).Name()
+		if tag, ok := byName[name]; ok {
+			out[i] = tag
+			used[name] = struct{}{}
+		}
+	}
+	for name := range byName {
+		if _, ok := used[name]; !ok {
+			return nil, fmt.Errorf("//injector:param %s: no such parameter", name)
+		}
 	}
+	return out, nil
 }
 
-func isNamedOrPtrToNamed(t types.Type) bool {
-	switch tt := t.(type) {
-	case *types.Named:
-		return true
+// unsupportedResultType explains why t cannot be the result type of a provider,
+// or returns "" if it can.
+//
+// Allowed:
+// - named types: T
+// - interface types (including named interfaces): interface{...} or type I interface{...}
+// - composite types: []T, [N]T, map[K]V, chan T, func(...) ...
+// - pointers to any of the above: *T
+func unsupportedResultType(t types.Type) string {
+	switch tt := types.Unalias(t).(type) {
+	case *types.Named, *types.Interface, *types.Slice, *types.Array, *types.Map, *types.Chan, *types.Signature:
+		return ""
 	case *types.Pointer:
-		_, ok := tt.Elem().(*types.Named)
-		return ok
+		return unsupportedResultType(tt.Elem())
+	case *types.Basic:
+		return "a predeclared type such as string or int does not identify a dependency; declare a named type (e.g. type DSN string)"
+	case *types.Struct:
+		return "an anonymous struct cannot be referred to by name; declare a named struct type"
+	case *types.TypeParam:
+		return "a bare type parameter would match every requested type; return a type built from it instead"
 	default:
-		return false
+		return "unknown type"
 	}
 }
 
+// isCleanupFunc reports whether t is exactly func().
+func isCleanupFunc(t types.Type) bool {
+	sig, ok := t.(*types.Signature)
+	return ok && sig.Params().Len() == 0 && sig.Results().Len() == 0 && !sig.Variadic()
+}
+
 func isBuiltinError(t types.Type) bool {
 	obj := types.Universe.Lookup("error")
 	if obj == nil {
@@ -0,0 +1,252 @@
+package scan
+
+import (
+	"go/types"
+	"slices"
+	"testing"
+)
+
+func TestCollectProvidersStrict(t *testing.T) {
+	const src = `package app
+
+type DB struct{}
+type Repo struct{}
+type Cache struct{}
+
+//injector:provide
+func NewDB() *DB { return &DB{} }
+
+func NewRepo(*DB) *Repo { return &Repo{} }
+`
+	const providersPkg = `//injector:providers
+package infra
+
+type Cache struct{}
+
+func NewCache() *Cache { return &Cache{} }
+`
+
+	tests := []struct {
+		name        string
+		strict      bool
+		extra       string
+		want        []string
+		wantSkipped []string
+		wantErr     string
+	}{
+		{
+			name: "every function with a provider shape",
+			want: []string{"NewDB", "NewRepo", "NewCache"},
+		},
+		{
+			name:        "strict mode collects annotated functions and packages",
+			strict:      true,
+			want:        []string{"NewDB", "NewCache"},
+			wantSkipped: []string{"NewRepo"},
+		},
+		{
+			name:    "annotated function without a provider shape",
+			extra:   "\n//injector:provide\nfunc Close(*DB) error { return nil }\n",
+			wantErr: "Close is annotated with //injector:provide but",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgs := loadPackages(t,
+				testPackage{path: "example.com/app", files: map[string]string{"app.go": src + tt.extra}},
+				testPackage{path: "example.com/infra", files: map[string]string{"infra.go": providersPkg}},
+			)
+			ps, skipped, err := CollectProviders(pkgs, ProviderOptions{Strict: tt.strict})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := providerNames(ps); !slices.Equal(got, tt.want) {
+				t.Errorf("providers = %v, want %v", got, tt.want)
+			}
+			if got := skippedNames(skipped); !slices.Equal(got, tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", got, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestCollectProvidersExclude(t *testing.T) {
+	pkgs := []testPackage{
+		{path: "example.com/app", files: map[string]string{"app.go": `package app
+
+type DB struct{}
+
+func NewDB() *DB { return &DB{} }
+
+//injector:ignore
+func NewTestDB() *DB { return &DB{} }
+`}},
+		{path: "example.com/app/mock", files: map[string]string{"mock.go": `package mock
+
+type Mailer struct{}
+
+func NewMailer() *Mailer { return &Mailer{} }
+`}},
+		{path: "example.com/legacy", files: map[string]string{"legacy.go": `package legacy
+
+type Client struct{}
+
+func NewClient() *Client { return &Client{} }
+`}},
+	}
+
+	tests := []struct {
+		name        string
+		exclude     []string
+		want        []string
+		wantSkipped []string
+		wantErr     string
+	}{
+		{
+			name:        "ignored function",
+			want:        []string{"NewDB", "NewMailer", "NewClient"},
+			wantSkipped: []string{"NewTestDB"},
+		},
+		{
+			name:        "pattern",
+			exclude:     []string{"example.com/*/mock", "example.com/legacy"},
+			want:        []string{"NewDB"},
+			wantSkipped: []string{"NewTestDB", "NewMailer", "NewClient"},
+		},
+		{
+			name:        "package and its subpackages",
+			exclude:     []string{"example.com/app/..."},
+			want:        []string{"NewClient"},
+			wantSkipped: []string{"NewDB", "NewTestDB", "NewMailer"},
+		},
+		{
+			name:    "invalid pattern",
+			exclude: []string{"example.com/["},
+			wantErr: `invalid exclude pattern "example.com/["`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, skipped, err := CollectProviders(loadPackages(t, pkgs...), ProviderOptions{Exclude: tt.exclude})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := providerNames(ps); !slices.Equal(got, tt.want) {
+				t.Errorf("providers = %v, want %v", got, tt.want)
+			}
+			if got := skippedNames(skipped); !slices.Equal(got, tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", got, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestCollectProvidersResultTypes(t *testing.T) {
+	const src = `package app
+
+type Route struct{}
+
+func NewRoutes() []*Route { return nil }
+func NewTable() map[string]*Route { return nil }
+func NewHandler() func(int) error { return nil }
+func NewEvents() <-chan *Route { return nil }
+func NewName() string { return "" }
+func NewPoint() struct{ X int } { return struct{ X int }{} }
+func NewAny[T any]() T { var v T; return v }
+func NewRoute() (*Route, error) { return &Route{}, nil }
+`
+
+	tests := []struct {
+		name    string
+		extra   string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "composite and function results",
+			want: []string{"NewRoutes", "NewTable", "NewHandler", "NewEvents", "NewRoute"},
+		},
+		{
+			name:    "annotated function with a predeclared result",
+			extra:   "\n//injector:provide\nfunc NewPort() int { return 0 }\n",
+			wantErr: "NewPort is annotated with //injector:provide but its result int is not supported: a predeclared type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgs := loadPackages(t, testPackage{path: "example.com/app", files: map[string]string{"app.go": src + tt.extra}})
+			ps, _, err := CollectProviders(pkgs, ProviderOptions{})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+			if got := providerNames(ps); !slices.Equal(got, tt.want) {
+				t.Errorf("providers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectProvidersTests(t *testing.T) {
+	pkgs := loadPackages(t, testPackage{path: "example.com/app", files: map[string]string{
+		"app.go": `package app
+
+type Clock interface{ Now() int64 }
+
+func NewClock() Clock { return nil }
+`,
+		"clock_test.go": `package app
+
+func NewFakeClock() Clock { return nil }
+
+type FakeRepo struct {
+	Clock Clock
+}
+
+type Fakes struct {
+	Clock Clock
+}
+`,
+	}})
+	scope := pkgs[0].Types.Scope()
+
+	ps, _, err := CollectProviders(pkgs, ProviderOptions{
+		Structs: []StructType{{Type: scope.Lookup("FakeRepo").Type(), All: true}},
+		Fields:  []types.Type{scope.Lookup("Fakes").Type()},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var tests []string
+	for _, p := range ps {
+		if p.Test {
+			tests = append(tests, p.Name)
+		}
+	}
+	if want := []string{"NewFakeClock", "FakeRepo", "Fakes.Clock"}; !slices.Equal(tests, want) {
+		t.Errorf("test providers = %v, want %v", tests, want)
+	}
+}
+
+func TestIsTestPosition(t *testing.T) {
+	tests := []struct {
+		pos  string
+		want bool
+	}{
+		{pos: "/src/app/clock_test.go:3:6", want: true},
+		{pos: "/src/app/clock.go:3:6", want: false},
+		{pos: "/src/app.go:v2/clock_test.go:3:6", want: true},
+		{pos: "/src/app_test.go:v2/clock.go:3:6", want: false},
+		{pos: `C:\src\app\clock_test.go:3:6`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pos, func(t *testing.T) {
+			if got := isTestPosition(tt.pos); got != tt.want {
+				t.Errorf("isTestPosition(%q) = %v, want %v", tt.pos, got, tt.want)
+			}
+		})
+	}
+}
@@ -32,6 +32,26 @@ func joinLines(lines []string) string {
 	return b.String()
 }
 
+// positionFile returns the file name of pos, as formatted by position.
+func positionFile(pos string) string {
+	// pos format: "/path/to/file.go:line:col"
+	// The line and column are cut from the right, so colons in the path are kept.
+	i := strings.LastIndexByte(pos, ':')
+	if i < 0 {
+		return pos
+	}
+	j := strings.LastIndexByte(pos[:i], ':')
+	if j < 0 {
+		return pos[:i]
+	}
+	return pos[:j]
+}
+
+// isTestPosition reports whether pos, as formatted by position, is in a _test.go file.
+func isTestPosition(pos string) bool {
+	return strings.HasSuffix(positionFile(pos), "_test.go")
+}
+
 func splitDirectives(raw string) ([]string, error) {
 	r := csv.NewReader(strings.NewReader(raw))
 	r.Comma = ','
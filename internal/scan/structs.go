@@ -0,0 +1,129 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// StructType is a struct type built by assigning its fields from the graph.
+type StructType struct {
+	// Type is the provided type, T or *T.
+	Type types.Type
+	// All fills every exported field instead of only the fields with an `inject` tag.
+	All bool
+}
+
+// StructTypes returns the types of container fields marked with `inject:"struct"`.
+// They are built as struct providers (see ProviderOptions.Structs).
+func StructTypes(cs []ContainerSpec) []StructType {
+	var out []StructType
+	for _, c := range cs {
+		for _, f := range c.Fields {
+			if f.Inject.Struct && f.Type != nil {
+				out = append(out, StructType{Type: f.Type, All: f.Inject.StructAll})
+			}
+		}
+	}
+	return out
+}
+
+// collectStructProviders returns the struct providers of the types in pkg
+// annotated with `//injector:struct`. They provide a pointer to the type.
+func collectStructProviders(pkg *packages.Package) ([]ProviderSpec, error) {
+	var out []ProviderSpec
+	var errs []string
+
+	for _, file := range pkg.Syntax {
+		if file == nil {
+			continue
+		}
+		for _, td := range typeDecls(file) {
+			ts := td.Spec
+			anns, err := parseTypeAnnotations(parseAnnotations(td.Doc))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: invalid annotation on %s: %v", position(pkg.Fset, ts.Pos()), ts.Name.Name, err))
+				continue
+			}
+			if !anns.Struct {
+				continue
+			}
+			obj, ok := pkg.TypesInfo.Defs[ts.Name].(*types.TypeName)
+			if !ok {
+				errs = append(errs, fmt.Sprintf("%s: type information is missing for %s", position(pkg.Fset, ts.Pos()), ts.Name.Name))
+				continue
+			}
+			spec, err := structProvider(pkg.Fset, types.NewPointer(obj.Type()), anns.StructAll)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", position(pkg.Fset, ts.Pos()), err))
+				continue
+			}
+			out = append(out, spec)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.New(joinLines(errs))
+	}
+	return out, nil
+}
+
+// structProvider builds the provider of t, a struct type or a pointer to one,
+// whose dependencies are its fields with an `inject` tag, or all exported fields if all is set.
+// Field tags accept the same directives as provider parameters.
+func structProvider(fset *token.FileSet, t types.Type, all bool) (ProviderSpec, error) {
+	named := namedOf(t)
+	if named == nil {
+		return ProviderSpec{}, fmt.Errorf("struct provider %s must be a named type or a pointer to a named type", t)
+	}
+	obj := named.Obj()
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return ProviderSpec{}, fmt.Errorf("struct provider %s is not a struct", obj.Name())
+	}
+	if named.TypeParams().Len() > 0 {
+		return ProviderSpec{}, fmt.Errorf("generic type %s cannot be a struct provider", obj.Name())
+	}
+
+	spec := ProviderSpec{
+		PkgPath:      obj.Pkg().Path(),
+		PkgName:      obj.Pkg().Name(),
+		Name:         obj.Name(),
+		ResultType:   t,
+		ResultString: typeStringByName(t),
+		Struct:       true,
+		Position:     position(fset, obj.Pos()),
+	}
+
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		raw, tagged := reflect.StructTag(st.Tag(i)).Lookup("inject")
+		if !tagged && !all {
+			continue
+		}
+		if !f.Exported() {
+			if tagged {
+				return ProviderSpec{}, fmt.Errorf("struct provider %s: unexported field %s cannot be injected", obj.Name(), f.Name())
+			}
+			continue
+		}
+
+		tag, err := parseInjectorTag(raw)
+		if err != nil {
+			return ProviderSpec{}, fmt.Errorf("struct provider %s: field %s: %w", obj.Name(), f.Name(), err)
+		}
+		if tag.Provider != "" || tag.Bind != "" || tag.Arg || tag.Lifecycle || tag.Lazy || tag.Module || tag.Struct || tag.Fields || len(tag.With) > 0 {
+			return ProviderSpec{}, fmt.Errorf("struct provider %s: field %s: only name, group, optional and transient are supported", obj.Name(), f.Name())
+		}
+
+		spec.Params = append(spec.Params, f.Type())
+		spec.ParamTags = append(spec.ParamTags, tag)
+		spec.Fields = append(spec.Fields, f.Name())
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,115 @@
+package scan
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCollectProvidersStructs(t *testing.T) {
+	const src = `package app
+
+type DB struct{}
+type Cache struct{}
+type Logger struct{}
+
+//injector:struct
+type Repo struct {
+	DB     *DB ` + "`inject:\"\"`" + `
+	Cache  *Cache ` + "`inject:\"name:Primary\"`" + `
+	Logger *Logger
+}
+
+type Service struct {
+	Repo   *Repo
+	Logger *Logger
+	count  int
+}
+
+type Handler struct {
+	Logger *Logger ` + "`inject:\"\"`" + `
+}
+`
+
+	tests := []struct {
+		name       string
+		extra      string
+		container  string
+		want       []string
+		wantFields map[string][]string
+		wantErr    string
+	}{
+		{
+			name:       "annotated struct with tagged fields",
+			want:       []string{"Repo"},
+			wantFields: map[string][]string{"Repo": {"DB", "Cache"}},
+		},
+		{
+			name:       "container field with every exported field",
+			container:  "Service *Service `inject:\"struct:all\"`",
+			want:       []string{"Repo", "Service"},
+			wantFields: map[string][]string{"Service": {"Repo", "Logger"}},
+		},
+		{
+			name:       "container field with tagged fields",
+			container:  "Handler Handler `inject:\"struct\"`",
+			want:       []string{"Repo", "Handler"},
+			wantFields: map[string][]string{"Handler": {"Logger"}},
+		},
+		{
+			name:    "tagged unexported field",
+			extra:   "\n//injector:struct\ntype Bad struct {\n\tdb *DB `inject:\"\"`\n}\n",
+			wantErr: "struct provider Bad: unexported field db cannot be injected",
+		},
+		{
+			name:    "field with an unsupported directive",
+			extra:   "\n//injector:struct\ntype Bad struct {\n\tDB *DB `inject:\"arg\"`\n}\n",
+			wantErr: "struct provider Bad: field DB: only name, group, optional and transient are supported",
+		},
+		{
+			name:    "generic struct",
+			extra:   "\n//injector:struct\ntype Box[T any] struct{}\n",
+			wantErr: "generic type Box cannot be a struct provider",
+		},
+		{
+			name:    "annotated type that is not a struct",
+			extra:   "\n//injector:struct\ntype Names []string\n",
+			wantErr: "struct provider Names is not a struct",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := src + tt.extra
+			if tt.container != "" {
+				code += "\ntype Container struct {\n\t" + tt.container + "\n}\n"
+			}
+			pkgs := loadPackages(t, testPackage{path: "example.com/app", files: map[string]string{"app.go": code}})
+
+			var ps []ProviderSpec
+			containers, err := CollectContainers(pkgs)
+			if err == nil {
+				ps, _, err = CollectProviders(pkgs, ProviderOptions{Structs: StructTypes(containers)})
+			}
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+
+			var structs []string
+			for _, p := range ps {
+				if !p.Struct {
+					continue
+				}
+				structs = append(structs, p.Name)
+				if want, ok := tt.wantFields[p.Name]; ok && !slices.Equal(p.Fields, want) {
+					t.Errorf("%s: fields = %v, want %v", p.Name, p.Fields, want)
+				}
+				if len(p.Params) != len(p.Fields) || len(p.ParamTags) != len(p.Fields) {
+					t.Errorf("%s: %d params and %d tags for %d fields", p.Name, len(p.Params), len(p.ParamTags), len(p.Fields))
+				}
+			}
+			if !slices.Equal(structs, tt.want) {
+				t.Errorf("struct providers = %v, want %v", structs, tt.want)
+			}
+		})
+	}
+}
@@ -8,12 +8,49 @@ import (
 
 // InjectTag represents a parsed `inject:"..."` struct tag.
 type InjectTag struct {
+	Provider  string
+	Name      string
+	Bind      string
+	Arg       bool
+	Lifecycle bool
+	Lazy      bool
+	Proxy     bool
+	Group     string
+	Optional  bool
+	Module    bool
+	Struct    bool
+	StructAll bool
+	Fields    bool
+	Transient bool
+	Compose   bool
+	With      []ScopedOverride
+}
+
+// ScopedOverride is a `with:<TypeExpr>=<provider>` directive.
+type ScopedOverride struct {
+	Type     string
 	Provider string
 }
 
 // parseInjectorTag parses a raw struct tag value for the `inject` key.
+// Directives are either key:value pairs or bare flags.
 // Supported directives (comma-separated):
 // - provider:<FuncName>
+// - name:<qualifier>
+// - bind:<TypeExpr>
+// - arg
+// - lifecycle
+// - lazy
+// - lazy:proxy
+// - group:<name>
+// - optional
+// - module
+// - struct
+// - struct:all
+// - fields
+// - transient
+// - compose
+// - with:<TypeExpr>=<FuncName> (repeatable)
 func parseInjectorTag(raw string) (InjectTag, error) {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -29,7 +66,8 @@ func parseInjectorTag(raw string) (InjectTag, error) {
 	for _, part := range parts {
 		key, val, ok := cutKV(part)
 		if !ok {
-			return InjectTag{}, errors.New("invalid injector tag directive")
+			// Bare flag directive (e.g. `arg`).
+			key = strings.TrimSpace(part)
 		}
 
 		switch key {
@@ -41,10 +79,136 @@ func parseInjectorTag(raw string) (InjectTag, error) {
 				return InjectTag{}, errors.New("provider already set")
 			}
 			out.Provider = val
+		case "name":
+			if val == "" {
+				return InjectTag{}, errors.New("name requires a value")
+			}
+			if out.Name != "" {
+				return InjectTag{}, errors.New("name already set")
+			}
+			out.Name = val
+		case "bind":
+			if val == "" {
+				return InjectTag{}, errors.New("bind requires a value")
+			}
+			if out.Bind != "" {
+				return InjectTag{}, errors.New("bind already set")
+			}
+			out.Bind = val
+		case "arg":
+			if ok {
+				return InjectTag{}, errors.New("arg takes no value")
+			}
+			out.Arg = true
+		case "lifecycle":
+			if ok {
+				return InjectTag{}, errors.New("lifecycle takes no value")
+			}
+			out.Lifecycle = true
+		case "lazy":
+			if ok && val != "proxy" {
+				return InjectTag{}, fmt.Errorf("unknown lazy mode %q", val)
+			}
+			if out.Lazy {
+				return InjectTag{}, errors.New("lazy already set")
+			}
+			out.Lazy = true
+			out.Proxy = ok
+		case "group":
+			if val == "" {
+				return InjectTag{}, errors.New("group requires a value")
+			}
+			if out.Group != "" {
+				return InjectTag{}, errors.New("group already set")
+			}
+			out.Group = val
+		case "optional":
+			if ok {
+				return InjectTag{}, errors.New("optional takes no value")
+			}
+			out.Optional = true
+		case "module":
+			if ok {
+				return InjectTag{}, errors.New("module takes no value")
+			}
+			out.Module = true
+		case "struct":
+			if ok && val != "all" {
+				return InjectTag{}, fmt.Errorf("unknown struct mode %q", val)
+			}
+			if out.Struct {
+				return InjectTag{}, errors.New("struct already set")
+			}
+			out.Struct = true
+			out.StructAll = ok
+		case "fields":
+			if ok {
+				return InjectTag{}, errors.New("fields takes no value")
+			}
+			out.Fields = true
+		case "transient":
+			if ok {
+				return InjectTag{}, errors.New("transient takes no value")
+			}
+			out.Transient = true
+		case "compose":
+			if ok {
+				return InjectTag{}, errors.New("compose takes no value")
+			}
+			out.Compose = true
+		case "with":
+			typ, provider, found := strings.Cut(val, "=")
+			typ, provider = strings.TrimSpace(typ), strings.TrimSpace(provider)
+			if !found || typ == "" || provider == "" {
+				return InjectTag{}, errors.New("with requires a value of the form <type>=<provider>")
+			}
+			for _, w := range out.With {
+				if w.Type == typ {
+					return InjectTag{}, fmt.Errorf("with:%s already set", typ)
+				}
+			}
+			out.With = append(out.With, ScopedOverride{Type: typ, Provider: provider})
 		default:
 			return InjectTag{}, errors.New("unknown injector tag directive")
 		}
 	}
 
+	if out.Provider != "" && out.Bind != "" {
+		return InjectTag{}, errors.New("provider and bind are mutually exclusive")
+	}
+	if out.Arg && (out.Provider != "" || out.Bind != "") {
+		return InjectTag{}, errors.New("arg cannot be combined with provider or bind")
+	}
+	if out.Group != "" && (out.Provider != "" || out.Name != "" || out.Bind != "" || out.Arg) {
+		return InjectTag{}, errors.New("group cannot be combined with provider, name, bind or arg")
+	}
+	if out.Optional && (out.Provider != "" || out.Arg) {
+		return InjectTag{}, errors.New("optional cannot be combined with provider or arg")
+	}
+	if out.Module && (out.Group != "" || out.Lazy || out.Optional) {
+		return InjectTag{}, errors.New("module cannot be combined with group, lazy or optional")
+	}
+	if out.Struct && (out.Provider != "" || out.Bind != "" || out.Arg || out.Group != "" || out.Module) {
+		return InjectTag{}, errors.New("struct cannot be combined with provider, bind, arg, group or module")
+	}
+	if out.Fields && (out.Group != "" || out.Lazy || out.Optional || out.Struct) {
+		return InjectTag{}, errors.New("fields cannot be combined with group, lazy, optional or struct")
+	}
+	if out.Transient && (out.Arg || out.Lazy || out.Group != "" || out.Module || out.Fields) {
+		return InjectTag{}, errors.New("transient cannot be combined with arg, lazy, group, module or fields")
+	}
+	if len(out.With) > 0 && (out.Arg || out.Lazy || out.Group != "" || out.Fields) {
+		return InjectTag{}, errors.New("with cannot be combined with arg, lazy, group or fields")
+	}
+	if out.Compose && (out.Provider != "" || out.Bind != "" || out.Arg || out.Lazy || out.Group != "" || out.Optional || out.Module || out.Struct || out.Fields || out.Transient || len(out.With) > 0) {
+		return InjectTag{}, errors.New("compose can only be combined with name")
+	}
+	if out.Lazy && out.Arg {
+		return InjectTag{}, errors.New("lazy cannot be combined with arg")
+	}
+	if out.Lifecycle && (out.Provider != "" || out.Name != "" || out.Bind != "" || out.Arg || out.Lazy || out.Group != "" || out.Optional || out.Module || out.Struct || out.Fields || out.Transient || out.Compose || len(out.With) > 0) {
+		return InjectTag{}, errors.New("lifecycle cannot be combined with other directives")
+	}
+
 	return out, nil
 }
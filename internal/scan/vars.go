@@ -0,0 +1,149 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// varDecl is a package-level variable name with the doc comment of its declaration.
+type varDecl struct {
+	Name *ast.Ident
+	Doc  *ast.CommentGroup
+}
+
+// varDecls returns the package-level variable names of a file in source order.
+// A declaration with a single spec documents that spec (`// Doc` above `var X = ...`).
+func varDecls(file *ast.File) []varDecl {
+	var out []varDecl
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			doc := vs.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			for _, name := range vs.Names {
+				out = append(out, varDecl{Name: name, Doc: doc})
+			}
+		}
+	}
+	return out
+}
+
+// collectVarProviders returns the package-level variables of pkg annotated with `//injector:provide`.
+// A variable of an unnamed function type is called like a provider function;
+// any other variable provides its own value.
+// Variables without the annotation are never collected, even outside strict mode.
+func collectVarProviders(pkg *packages.Package, excludedBy string) ([]ProviderSpec, []SkippedProvider, error) {
+	var out []ProviderSpec
+	var skipped []SkippedProvider
+	var errs []string
+
+	for _, file := range pkg.Syntax {
+		if file == nil || isInjectorGenerated(file) {
+			continue
+		}
+		for _, vd := range varDecls(file) {
+			name := vd.Name.Name
+			if name == "_" {
+				continue
+			}
+			pos := position(pkg.Fset, vd.Name.Pos())
+
+			anns, err := parseProviderAnnotations(parseAnnotations(vd.Doc))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: invalid annotation on %s: %v", pos, name, err))
+				continue
+			}
+			if !anns.Provide {
+				continue
+			}
+			if excludedBy != "" {
+				skipped = append(skipped, SkippedProvider{
+					PkgPath:  pkg.PkgPath,
+					Name:     name,
+					Reason:   fmt.Sprintf("package excluded by %q", excludedBy),
+					Position: pos,
+				})
+				continue
+			}
+
+			obj, ok := pkg.TypesInfo.Defs[vd.Name].(*types.Var)
+			if !ok {
+				errs = append(errs, fmt.Sprintf("%s: type information is missing for %s", pos, name))
+				continue
+			}
+			spec, err := varProvider(obj, anns)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s is annotated with //injector:provide but %v", pos, name, err))
+				continue
+			}
+			spec.Position = pos
+			out = append(out, spec)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errors.New(joinLines(errs))
+	}
+	return out, skipped, nil
+}
+
+// varProvider builds the provider of a package-level variable.
+func varProvider(obj *types.Var, anns providerAnnotations) (ProviderSpec, error) {
+	spec := ProviderSpec{
+		PkgPath:   obj.Pkg().Path(),
+		PkgName:   obj.Pkg().Name(),
+		Name:      obj.Name(),
+		Qualifier: anns.Qualifier,
+		Groups:    anns.Groups,
+		Var:       true,
+		Transient: anns.Transient,
+		Profiles:  anns.Profiles,
+	}
+
+	sig, ok := types.Unalias(obj.Type()).(*types.Signature)
+	if !ok {
+		if why := unsupportedResultType(obj.Type()); why != "" {
+			return ProviderSpec{}, fmt.Errorf("its type %s is not supported: %s", typeStringByName(obj.Type()), why)
+		}
+		if len(anns.Params) > 0 {
+			return ProviderSpec{}, errors.New("it is not a function and has no parameters")
+		}
+		if anns.Transient {
+			return ProviderSpec{}, errors.New("it is not a function and always provides the same value")
+		}
+		spec.ResultType = obj.Type()
+		spec.ResultString = typeStringByName(obj.Type())
+		spec.Value = true
+		return spec, nil
+	}
+
+	resType, returnCleanup, returnError, reason := providerResults(sig)
+	if reason != "" {
+		return ProviderSpec{}, errors.New(reason)
+	}
+	paramTags, err := extractParamTags(sig, anns.Params)
+	if err != nil {
+		return ProviderSpec{}, err
+	}
+	spec.ResultType = resType
+	spec.ResultString = typeStringByName(resType)
+	spec.ReturnCleanup = returnCleanup
+	spec.ReturnError = returnError
+	spec.Params = extractParamTypes(sig)
+	spec.ParamTags = paramTags
+	return spec, nil
+}
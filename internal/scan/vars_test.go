@@ -0,0 +1,88 @@
+package scan
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCollectProvidersVars(t *testing.T) {
+	const src = `package app
+
+type Clock interface{ Now() int64 }
+type Logger struct{}
+type DB struct{}
+
+type systemClock struct{}
+
+func (systemClock) Now() int64 { return 0 }
+
+//injector:provide
+var DefaultClock Clock = systemClock{}
+
+//injector:provide
+var NewDB = func(*Logger) (*DB, error) { return &DB{}, nil }
+
+var Unannotated = &Logger{}
+`
+
+	tests := []struct {
+		name        string
+		extra       string
+		exclude     []string
+		want        []string
+		wantValues  []string
+		wantSkipped []string
+		wantErr     string
+	}{
+		{
+			name:       "annotated values and function variables",
+			want:       []string{"DefaultClock", "NewDB"},
+			wantValues: []string{"DefaultClock"},
+		},
+		{
+			name:        "variables of an excluded package",
+			exclude:     []string{"example.com/app"},
+			wantSkipped: []string{"DefaultClock", "NewDB"},
+		},
+		{
+			name:    "variable of a predeclared type",
+			extra:   "\n//injector:provide\nvar Port = 8080\n",
+			wantErr: "Port is annotated with //injector:provide but its type int is not supported",
+		},
+		{
+			name:    "value variable with parameter annotations",
+			extra:   "\n//injector:provide\n//injector:param db name:Primary\nvar Default = &Logger{}\n",
+			wantErr: "Default is annotated with //injector:provide but it is not a function and has no parameters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgs := loadPackages(t, testPackage{path: "example.com/app", files: map[string]string{"app.go": src + tt.extra}})
+			ps, skipped, err := CollectProviders(pkgs, ProviderOptions{Exclude: tt.exclude})
+			if !checkError(t, err, tt.wantErr) {
+				return
+			}
+
+			var vars, values []string
+			for _, p := range ps {
+				if !p.Var {
+					continue
+				}
+				vars = append(vars, p.Name)
+				if p.Value {
+					values = append(values, p.Name)
+				}
+			}
+			if !slices.Equal(vars, tt.want) {
+				t.Errorf("var providers = %v, want %v", vars, tt.want)
+			}
+			if !slices.Equal(values, tt.wantValues) {
+				t.Errorf("value providers = %v, want %v", values, tt.wantValues)
+			}
+			if got := skippedNames(skipped); !slices.Equal(got, tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", got, tt.wantSkipped)
+			}
+		})
+	}
+}
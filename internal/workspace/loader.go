@@ -3,6 +3,7 @@ package workspace
 import (
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"golang.org/x/tools/go/packages"
@@ -15,6 +16,9 @@ type LoadConfig struct {
 
 	// Tests includes test files/packages when true.
 	Tests bool
+
+	// Env overrides environment variables of `go list`, such as GOOS and GOARCH.
+	Env []string
 }
 
 // Loaded holds loaded packages and the effective config.
@@ -43,6 +47,10 @@ func Load(patterns []string, cfg LoadConfig) (*Loaded, error) {
 		Tests: cfg.Tests,
 	}
 
+	if len(cfg.Env) > 0 {
+		pc.Env = append(os.Environ(), cfg.Env...)
+	}
+
 	if len(cfg.BuildTags) > 0 {
 		pc.BuildFlags = []string{fmt.Sprintf("-tags=%s", joinTags(cfg.BuildTags))}
 	}